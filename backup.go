@@ -15,12 +15,17 @@
 package goenv
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"filippo.io/age"
 	"github.com/gobwas/glob"
 	"github.com/mitchellh/go-homedir"
 	"github.com/moisespsena-go/error-wrap"
@@ -29,8 +34,9 @@ import (
 type ValidFunc func(pth string, info os.FileInfo) bool
 
 type Patterns struct {
-	values []glob.Glob
-	m      map[string]bool
+	values  []glob.Glob
+	strings []string
+	m       map[string]bool
 }
 
 func (e *Patterns) Append(values ...string) (err error) {
@@ -52,6 +58,7 @@ func (e *Patterns) Append(values ...string) (err error) {
 
 		e.m[value] = true
 		e.values = append(e.values, g)
+		e.strings = append(e.strings, value)
 	}
 	return nil
 }
@@ -60,6 +67,10 @@ func (e *Patterns) Values() []glob.Glob {
 	return e.values
 }
 
+func (e *Patterns) Strings() []string {
+	return e.strings
+}
+
 func (e *Patterns) ValidFunc() ValidFunc {
 	return func(pth string, info os.FileInfo) bool {
 		if len(e.values) == 0 {
@@ -104,17 +115,21 @@ type BackupOptions struct {
 	DefaultBackup bool
 	Writer        io.Writer
 	Patterns      Patterns
-}
 
-func (env *GoEnvCmd) Backup(name string, options *BackupOptions) error {
-	pth, err := env.Env.Backup(name, options)
-	if err != nil {
-		return fmt.Errorf("Backup for %q failed: %v", name, err)
-	}
-	if pth != "" {
-		fmt.Fprintf(os.Stdout, "Backup save on %q\n", pth)
-	}
-	return nil
+	// Recipients encrypts the backup with age if isn't empty.
+	Recipients []age.Recipient
+
+	// Store saves the backup as StoreName. If Target is a store URL (see
+	// BackupStoreFactories), the store is opened from it.
+	Store     BackupStore
+	StoreName string
+
+	// Progress receives the progress updates.
+	Progress ProgressFunc
+
+	// Codec is the archive codec (see BackupCodecExt). Defaults to
+	// backup.codec config.
+	Codec string
 }
 
 func (env *GoEnv) TempDir() (pth string, err error) {
@@ -134,13 +149,25 @@ func (env *GoEnv) TempDir() (pth string, err error) {
 }
 
 func (env *GoEnv) Backup(name string, options *BackupOptions) (string, error) {
+	return env.BackupContext(context.Background(), name, options)
+}
+
+// BackupContext is like Backup, but is canceled when ctx is done. On failure,
+// the partial backup file is removed.
+func (env *GoEnv) BackupContext(ctx context.Context, name string, options *BackupOptions) (string, error) {
+	env, unlock, err := env.LockEnv(true, name)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
 	pth, err := env.GetCheck(name)
 
 	if err != nil {
 		return "", err
 	}
 
-	excludeFile := filepath.Join(pth, ".goenv_settings", "backup_exclude")
+	excludeFile := filepath.Join(pth, SETTINGS_DIR, "backup_exclude")
 	ok, err := IsFile(excludeFile)
 	if err != nil {
 		return "", errwrap.Wrap(err, "Check file %q", excludeFile)
@@ -156,14 +183,118 @@ func (env *GoEnv) Backup(name string, options *BackupOptions) (string, error) {
 		}
 	}
 
+	if err = options.Patterns.Append(env.Config.Strings("backup.exclude")...); err != nil {
+		return "", errwrap.Wrap(err, "Parse backup.exclude config patterns")
+	}
+
+	codec := options.Codec
+	if codec == "" {
+		codec = env.Config.String("backup.codec")
+	}
+	ext, err := BackupCodecExt(codec)
+	if err != nil {
+		return "", err
+	}
+
+	goVersion, err := env.GoVersionName(name)
+	if err != nil {
+		return "", fmt.Errorf("Get Go version: %w", err)
+	}
+
+	manifest := NewBackupManifest(name)
+	manifest.GoVersion = goVersion
+	manifest.Exclude = options.Patterns.Strings()
+
+	exclude := options.Patterns.ExcludeFunc()
+	modCacheMode, err := env.modCacheMode(pth)
+	if err != nil {
+		return "", err
+	}
+	if modCacheMode == MODCACHE_SHARED {
+		// the shared cache is outside of enviroment, but excludes the stale
+		// isolated cache.
+		modDir := filepath.Join(pth, "pkg", "mod")
+		patternsExclude := exclude
+		exclude = func(p string, info os.FileInfo) bool {
+			return p == modDir || patternsExclude(p, info)
+		}
+		manifest.Exclude = append(manifest.Exclude, "pkg/mod")
+	}
+
+	doCompress := func(writer io.Writer) (err error) {
+		if len(options.Recipients) > 0 {
+			var w io.WriteCloser
+			if w, err = encryptWriter(writer, options.Recipients); err != nil {
+				return
+			}
+			defer func() {
+				if err2 := w.Close(); err == nil {
+					err = err2
+				}
+			}()
+			writer = w
+		}
+		return compress(ctx, pth, writer, codec, exclude, manifest,
+			newProgressTracker("backup", options.Progress))
+	}
+
+	backupName := name + "_" + TimeString(time.Now()) + ext
+	if len(options.Recipients) > 0 {
+		backupName += ENCRYPTED_EXT
+	}
+
+	store, storeName := options.Store, options.StoreName
+	location := storeName
+
+	if store == nil && IsBackupStoreURL(options.Target) {
+		urlStore, urlStoreName, err := OpenBackupStore(options.Target)
+		if err != nil {
+			return "", err
+		}
+		defer CloseBackupStore(urlStore)
+		if urlStoreName == "" {
+			urlStoreName = backupName
+		}
+		store, storeName = urlStore, urlStoreName
+		location = backupStoreLocation(options.Target, storeName)
+	}
+
+	if store != nil {
+		prune := storeName == backupName
+		if storeName == "" {
+			storeName = backupName
+			location = backupName
+			prune = true
+		}
+		reader, writer := io.Pipe()
+		go func() {
+			writer.CloseWithError(doCompress(writer))
+		}()
+		err = store.Put(storeName, reader)
+		reader.CloseWithError(err)
+		if err != nil {
+			return "", fmt.Errorf("Put %q: %w", storeName, err)
+		}
+		if prune {
+			if err = env.pruneBackups(store, name); err != nil {
+				return location, err
+			}
+		}
+		return location, nil
+	}
+
 	if options.Target != "" {
 		writer, err := os.Create(options.Target)
 		if err != nil {
 			return "", err
 		}
-		defer writer.Close()
-		err = compress(pth, writer, options.Patterns.ExcludeFunc())
-		return options.Target, err
+		err = doCompress(writer)
+		writer.Close()
+		if err != nil {
+			os.Remove(options.Target)
+			return "", err
+		}
+		return options.Target, nil
 	}
 
 	if options.DefaultBackup {
@@ -180,23 +311,59 @@ func (env *GoEnv) Backup(name string, options *BackupOptions) (string, error) {
 			}
 		}
 
-		target := filepath.Join(bkpDir, name+"_"+TimeString(time.Now())+".tar.gz")
+		target := filepath.Join(bkpDir, backupName)
 		writer, err := os.Create(target)
 		if err != nil {
 			return "", err
 		}
-		defer writer.Close()
-		err = compress(pth, writer, options.Patterns.ExcludeFunc())
-		return target, err
+		err = doCompress(writer)
+		writer.Close()
+		if err != nil {
+			os.Remove(target)
+			return "", err
+		}
+		return target, env.pruneBackups(NewDirBackupStore(bkpDir), name)
 	}
 
 	if options.Writer != nil {
-		return "", compress(pth, options.Writer, options.Patterns.ExcludeFunc())
+		return "", doCompress(options.Writer)
 	}
 
 	return "", fmt.Errorf("No target defined.")
 }
 
+// pruneBackups removes the oldest backups of enviroment saved on store,
+// keeping the newest backup.retention config backups.
+func (env *GoEnv) pruneBackups(store BackupStore, name string) error {
+	keep := env.Config.Int("backup.retention")
+	if keep <= 0 {
+		return nil
+	}
+	items, err := store.List(name + "_")
+	if err != nil {
+		return fmt.Errorf("List backups of %q: %w", name, err)
+	}
+	var backups []*BackupStoreItem
+	for _, item := range items {
+		// skip backups of other enviroments with same prefix, as "name_other"
+		rest := strings.TrimPrefix(item.Name, name+"_")
+		if !strings.Contains(item.Name, "/") && rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+			backups = append(backups, item)
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ModTime.Before(backups[j].ModTime) ||
+			(backups[i].ModTime.Equal(backups[j].ModTime) && backups[i].Name < backups[j].Name)
+	})
+	for len(backups) > keep {
+		if err = store.Delete(backups[0].Name); err != nil {
+			return fmt.Errorf("Remove old backup %q: %w", backups[0].Name, err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
 type RestoreOptions struct {
 	Source    string
 	OverWrite bool
@@ -206,24 +373,44 @@ type RestoreOptions struct {
 	Archive   bool
 	Verbose   bool
 	Trial     bool
-}
 
-func (env *GoEnvCmd) Restore(options *RestoreOptions) error {
-	pth, err := env.Env.Restore(options)
-	if err != nil {
-		return fmt.Errorf("Restore failed: %v", err)
-	}
-	if pth != "" {
-		fmt.Fprintf(os.Stdout, "Restore saved on %q\n", pth)
-	}
-	return nil
+	// Identities returns the identities used to decrypt encrypted backups.
+	Identities IdentitiesFunc
+
+	// Progress receives the progress updates.
+	Progress ProgressFunc
+
+	// InstallGoVersion is called when the Go version bound by the backup
+	// manifest isn't installed. If returns true, the version is installed.
+	InstallGoVersion func(manifest *BackupManifest) bool
 }
 
 func (env *GoEnv) Restore(options *RestoreOptions) (string, error) {
+	return env.RestoreContext(context.Background(), options)
+}
+
+// RestoreContext is like Restore, but is canceled when ctx is done. On
+// failure, the enviroment created by restore is removed.
+func (env *GoEnv) RestoreContext(ctx context.Context, options *RestoreOptions) (string, error) {
 	var err error
-	var reader io.ReadSeeker
+	var reader io.Reader
 
-	if options.Source != "" {
+	if IsBackupStoreURL(options.Source) {
+		store, storeName, err := OpenBackupStore(options.Source)
+		if err != nil {
+			return "", err
+		}
+		defer CloseBackupStore(store)
+		if storeName == "" {
+			return "", fmt.Errorf("The backup file name isn't defined on %q.", options.Source)
+		}
+		r, err := store.Get(storeName)
+		if err != nil {
+			return "", fmt.Errorf("Get %q: %w", storeName, err)
+		}
+		defer r.Close()
+		reader = r
+	} else if options.Source != "" {
 		src, err := homedir.Expand(options.Source)
 		if err != nil {
 			return "", err
@@ -241,11 +428,16 @@ func (env *GoEnv) Restore(options *RestoreOptions) (string, error) {
 		return "", fmt.Errorf("No source defined.")
 	}
 
+	if reader, err = decryptReader(reader, options.Identities); err != nil {
+		return "", err
+	}
+
 	//defer os.RemoveAll(dir)
 	bkp, err := NewBackupReader(reader, options.Archive)
 	if err != nil {
 		return "", err
 	}
+	bkp.Logger = env.Logger
 	name, err := bkp.GetRootName()
 	if err != nil {
 		return "", err
@@ -255,6 +447,12 @@ func (env *GoEnv) Restore(options *RestoreOptions) (string, error) {
 		name = options.Name
 	}
 
+	env, unlock, err := env.LockEnv(false, name)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
 	pth, err := env.GetPath(name, false)
 
 	if err != nil {
@@ -267,11 +465,17 @@ func (env *GoEnv) Restore(options *RestoreOptions) (string, error) {
 	}
 
 	if !exists || (options.Update || options.OverWrite) {
+		target := env.DbDir
 		if exists && options.OverWrite && !options.Trial {
-			err := os.RemoveAll(pth)
+			// extracts aside, so the enviroment is kept on failure
+			tmpDir, err := env.TempDir()
 			if err != nil {
-				return pth, err
+				return "", err
 			}
+			if target, err = ioutil.TempDir(tmpDir, "restore-"+name+"-"); err != nil {
+				return "", errwrap.Wrap(err, "Create restore directory")
+			}
+			defer os.RemoveAll(target)
 		}
 		opts := ExtractOptions(0)
 		if options.Verbose {
@@ -280,12 +484,62 @@ func (env *GoEnv) Restore(options *RestoreOptions) (string, error) {
 		if options.Trial {
 			opts |= Trial
 		}
-		err = bkp.Extract(name, env.DbDir, opts)
+		bkp.Progress = options.Progress
+		err = bkp.ExtractContext(ctx, name, target, opts)
 		if err != nil {
+			// don't keep the partial enviroment.
+			if !exists && !options.Trial {
+				os.RemoveAll(pth)
+			}
 			return "", err
 		}
+		if target != env.DbDir {
+			if err = replaceDir(pth, filepath.Join(target, name), filepath.Join(target, name+".old")); err != nil {
+				return "", err
+			}
+		}
+		if bkp.Manifest != nil && !options.Trial {
+			if err = env.restoreGoVersion(ctx, bkp.Manifest, options); err != nil {
+				return pth, err
+			}
+		}
 		return pth, nil
 	}
 
-	return pth, fmt.Errorf("Enviroment %q on %q exists.", name, pth)
+	return pth, &EnvError{name, pth, ErrEnvExists}
+}
+
+// replaceDir replaces the dir pth by src, moving pth to old. If src can't be
+// moved, pth is moved back.
+func replaceDir(pth, src, old string) error {
+	if err := os.Rename(pth, old); err != nil {
+		return errwrap.Wrap(err, "Move %q to %q", pth, old)
+	}
+	if err := os.Rename(src, pth); err != nil {
+		os.Rename(old, pth)
+		return errwrap.Wrap(err, "Move %q to %q", src, pth)
+	}
+	return nil
+}
+
+func (env *GoEnv) restoreGoVersion(ctx context.Context, manifest *BackupManifest, options *RestoreOptions) error {
+	if manifest.GoVersion == "" || options.InstallGoVersion == nil {
+		return nil
+	}
+	versions := NewGoVersions(env)
+	version, err := versions.Get(manifest.GoVersion)
+	if err != nil {
+		return err
+	}
+	if version != nil || !options.InstallGoVersion(manifest) {
+		return nil
+	}
+	installed, err := versions.InstallContext(ctx, strings.TrimPrefix(manifest.GoVersion, "go"))
+	if err != nil {
+		return fmt.Errorf("Install Go version %q: %w", manifest.GoVersion, err)
+	}
+	if len(installed) == 0 {
+		return fmt.Errorf("Go version %q isn't available for install.", manifest.GoVersion)
+	}
+	return nil
 }
@@ -0,0 +1,132 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newLargeTestEnv returns the GoEnv with enviroment "e1" of many files, so
+// the operations can be canceled partway.
+func newLargeTestEnv(t *testing.T) *GoEnv {
+	t.Helper()
+	env := newTestEnv(t, "e1")
+	data := strings.Repeat("x", 32*1024)
+	for i := 0; i < 20; i++ {
+		writeTestFile(t, filepath.Join(env.DbDir, "e1", "src", "e1", fmt.Sprintf("f%02d.txt", i)), data)
+	}
+	return env
+}
+
+// cancelOnFile returns the Progress function which cancels after n files.
+func cancelOnFile(t *testing.T, n int) (context.Context, ProgressFunc) {
+	t.Helper()
+	interval := ProgressInterval
+	ProgressInterval = 0
+	t.Cleanup(func() { ProgressInterval = interval })
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return ctx, func(p *Progress) {
+		if p.Files >= n {
+			cancel()
+		}
+	}
+}
+
+func TestBackupCancel(t *testing.T) {
+	env := newLargeTestEnv(t)
+	target := filepath.Join(t.TempDir(), "e1.tar.gz")
+	store := NewDirBackupStore(t.TempDir())
+
+	for name, options := range map[string]*BackupOptions{
+		"target":  {Target: target},
+		"default": {DefaultBackup: true},
+		"store":   {Store: store},
+	} {
+		ctx, progress := cancelOnFile(t, 3)
+		options.Progress = progress
+		if _, err := env.BackupContext(ctx, "e1", options); !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s: %v, want context.Canceled", name, err)
+		}
+	}
+
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("partial target isn't removed: %v", err)
+	}
+	if items, _ := ioutil.ReadDir(filepath.Join(env.DbDir, ".backup", "e1")); len(items) > 0 {
+		t.Errorf("partial default backup isn't removed: %s", items[0].Name())
+	}
+	if items, _ := ioutil.ReadDir(store.Dir); len(items) > 0 {
+		t.Errorf("partial store backup isn't removed: %s", items[0].Name())
+	}
+}
+
+func TestRestoreCancel(t *testing.T) {
+	env := newLargeTestEnv(t)
+	source := filepath.Join(t.TempDir(), "e1.tar.gz")
+	if _, err := env.Backup("e1", &BackupOptions{Target: source}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, progress := cancelOnFile(t, 3)
+	_, err := env.RestoreContext(ctx, &RestoreOptions{Source: source, Name: "e2", Progress: progress})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("%v, want context.Canceled", err)
+	}
+	if _, err = os.Stat(filepath.Join(env.DbDir, "e2")); !os.IsNotExist(err) {
+		t.Errorf("partial enviroment isn't removed: %v", err)
+	}
+
+	// the existing enviroment is kept, unless overwritten
+	ctx, progress = cancelOnFile(t, 3)
+	_, err = env.RestoreContext(ctx, &RestoreOptions{Source: source, Update: true, Progress: progress})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("update: %v, want context.Canceled", err)
+	}
+	if _, err = env.GetCheck("e1"); err != nil {
+		t.Errorf("updated enviroment is removed: %v", err)
+	}
+
+	// the overwritten enviroment is replaced only after the extraction
+	marker := filepath.Join(env.DbDir, "e1", "marker")
+	writeTestFile(t, marker, "old")
+	ctx, progress = cancelOnFile(t, 3)
+	_, err = env.RestoreContext(ctx, &RestoreOptions{Source: source, OverWrite: true, Progress: progress})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("overwrite: %v, want context.Canceled", err)
+	}
+	if got := readTestFile(t, marker); got != "old" {
+		t.Errorf("overwritten enviroment is changed: marker = %q", got)
+	}
+	if _, err = env.RestoreContext(context.Background(), &RestoreOptions{Source: source, OverWrite: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(marker); !os.IsNotExist(err) {
+		t.Errorf("enviroment isn't overwritten: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(env.DbDir, "e1", "src", "e1", "f00.txt")); err != nil {
+		t.Errorf("restored file: %v", err)
+	}
+	if items, _ := ioutil.ReadDir(filepath.Join(env.DbDir, ".tmp")); len(items) > 0 {
+		t.Errorf("restore directory isn't removed: %d items", len(items))
+	}
+}
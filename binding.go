@@ -0,0 +1,197 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/moisespsena-go/error-wrap"
+)
+
+const (
+	// SETTINGS_DIR is the enviroment settings directory.
+	SETTINGS_DIR = ".goenv_settings"
+	// GOVERSION_BINDING_NAME is the file, in SETTINGS_DIR, of enviroment Go
+	// version binding.
+	GOVERSION_BINDING_NAME = "goversion.json"
+
+	// SYS_VERSION uses the go command found on PATH.
+	SYS_VERSION = "sys"
+	// SYS_PIN_VERSION uses the system GOROOT resolved when the version is
+	// set, even if PATH changes later.
+	SYS_PIN_VERSION = "sys:pin"
+)
+
+// GoVersionBinding is the Go version bound to an enviroment.
+type GoVersionBinding struct {
+	// Version is the installed version name or "sys".
+	Version string `json:"version"`
+	// Pin is true if the system GOROOT is exported by activate script.
+	Pin bool `json:"pin,omitempty"`
+	// Root is the GOROOT exported by activate script. For unpinned "sys",
+	// it's only informative.
+	Root string `json:"root,omitempty"`
+	// GoVersion and OsInfo are the output of `go version` when bound.
+	GoVersion string `json:"go_version,omitempty"`
+	OsInfo    string `json:"os_info,omitempty"`
+}
+
+// IsSystem reports whether the binding uses the system Go.
+func (b *GoVersionBinding) IsSystem() bool {
+	return b.Version == SYS_VERSION
+}
+
+// Name returns the version name as accepted by GoVersions.Set.
+func (b *GoVersionBinding) Name() string {
+	if b.IsSystem() && b.Pin {
+		return SYS_PIN_VERSION
+	}
+	return b.Version
+}
+
+// activateRoot returns the GOROOT exported by activate script.
+func (b *GoVersionBinding) activateRoot() string {
+	if b.IsSystem() && !b.Pin {
+		return ""
+	}
+	return b.Root
+}
+
+// GoVersionBinding returns the Go version binding of enviroment. If the
+// binding file doesn't exists, the binding is detected from activate script.
+func (env *GoEnv) GoVersionBinding(name string) (*GoVersionBinding, error) {
+	pth, err := env.GetCheck(name)
+	if err != nil {
+		return nil, err
+	}
+	return readGoVersionBinding(pth)
+}
+
+func readGoVersionBinding(pth string) (b *GoVersionBinding, err error) {
+	p := filepath.Join(pth, SETTINGS_DIR, GOVERSION_BINDING_NAME)
+	data, err := ioutil.ReadFile(p)
+	if err == nil {
+		b = &GoVersionBinding{}
+		if err = json.Unmarshal(data, b); err != nil {
+			return nil, errwrap.Wrap(err, "Decode %q", p)
+		}
+		return b, nil
+	} else if !os.IsNotExist(err) {
+		return nil, errwrap.Wrap(err, "Read %q", p)
+	}
+
+	goRoot, err := readActivateGoRoot(filepath.Join(pth, "activate"))
+	if err != nil {
+		return nil, err
+	}
+	prefix := "$GOENVROOT/" + VERSIONS_BASENAME + "/"
+	switch {
+	case goRoot == "":
+		return &GoVersionBinding{Version: SYS_VERSION}, nil
+	case strings.HasPrefix(goRoot, prefix):
+		return &GoVersionBinding{Version: strings.TrimPrefix(goRoot, prefix), Root: goRoot}, nil
+	default:
+		return &GoVersionBinding{Version: SYS_VERSION, Pin: true, Root: goRoot}, nil
+	}
+}
+
+// BindGoVersion saves the binding and rewrites the activate script of
+// enviroment.
+func (env *GoEnv) BindGoVersion(envName string, b *GoVersionBinding) error {
+	env, unlock, err := env.LockEnv(false, envName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	pth, err := env.GetPath(envName, true)
+	if err != nil {
+		return err
+	}
+	if err = MkdirAll(pth, SETTINGS_DIR); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return errwrap.Wrap(err, "Encode binding")
+	}
+	p := filepath.Join(pth, SETTINGS_DIR, GOVERSION_BINDING_NAME)
+	if err = ioutil.WriteFile(p, append(data, '\n'), 0644); err != nil {
+		return errwrap.Wrap(err, "Write %q", p)
+	}
+	return env.CreateActivate(pth, b.activateRoot())
+}
+
+// EnvInfo is the Go version state of enviroment.
+type EnvInfo struct {
+	Name string
+	// GoVersion is the bound version name (see GoVersionBinding.Name).
+	GoVersion string
+	// GoRoot is the resolved GOROOT. For unpinned "sys", it's the current
+	// system GOROOT.
+	GoRoot     string
+	RootExists bool
+	// Broken is true if the toolchain of GoRoot doesn't exists.
+	Broken  bool
+	Binding *GoVersionBinding
+}
+
+// EnvInfo returns the Go version state of enviroment.
+func (env *GoEnv) EnvInfo(name string) (info *EnvInfo, err error) {
+	b, err := env.GoVersionBinding(name)
+	if err != nil {
+		return nil, err
+	}
+	info = &EnvInfo{Name: name, GoVersion: b.Name(), Binding: b}
+	if root := b.activateRoot(); root != "" {
+		info.GoRoot = filepath.Clean(strings.Replace(root, "$GOENVROOT", env.DbDir, 1))
+	} else {
+		system, err := GetSystemGoVersion()
+		if err != nil {
+			return nil, err
+		}
+		if system != nil {
+			info.GoRoot = system.Root
+		}
+	}
+	if info.GoRoot != "" {
+		if info.RootExists, err = IsFile(info.GoRoot, "bin", "go"); err != nil {
+			return nil, errwrap.Wrap(err, "Check GOROOT %q", info.GoRoot)
+		}
+	}
+	info.Broken = !info.RootExists
+	return
+}
+
+// EnvsInfo returns the EnvInfo of all enviroments.
+func (env *GoEnv) EnvsInfo() (infos []*EnvInfo, err error) {
+	names, err := env.Ls()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		info, err := env.EnvInfo(name)
+		if err != nil {
+			return nil, fmt.Errorf("Info of %q: %w", name, err)
+		}
+		infos = append(infos, info)
+	}
+	return
+}
@@ -0,0 +1,270 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/moisespsena-go/error-wrap"
+)
+
+// GO_SOURCE_REPOSITORY is the default git repository of Go sources.
+const GO_SOURCE_REPOSITORY = "https://go.googlesource.com/go"
+
+type BuildOptions struct {
+	// SourceDir is the Go source tree. If GitRef is defined, SourceDir is
+	// used as git repository instead of GitURL.
+	SourceDir string
+	// GitRef is the branch, tag or commit fetched from GitURL.
+	GitRef string
+	// GitURL defaults to GO_SOURCE_REPOSITORY.
+	GitURL string
+	// Bootstrap is the installed version used as GOROOT_BOOTSTRAP. If empty
+	// or "sys", uses the system Go.
+	Bootstrap string
+	// Log receives the build output. The output is ever saved to log file.
+	Log io.Writer
+}
+
+// BuildLogPath returns the path of build log file of version.
+func (vs *GoVersions) BuildLogPath(name string) string {
+	return filepath.Join(vs.Dir(), name+".build.log")
+}
+
+// Build builds the Go toolchain from sources using make.bash and installs it
+// as version name. On failure, nothing is installed.
+func (vs *GoVersions) Build(name string, options *BuildOptions) (version *GoVersion, err error) {
+	return vs.BuildContext(context.Background(), name, options)
+}
+
+// BuildContext is like Build, but the git and make.bash commands are killed
+// when ctx is done.
+func (vs *GoVersions) BuildContext(ctx context.Context, name string, options *BuildOptions) (version *GoVersion, err error) {
+	vs, unlock, err := vs.Lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	name = strings.ToLower(name)
+	if name == "" || name == "sys" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("Invalid version name %q.", name)
+	}
+	if options.SourceDir == "" && options.GitRef == "" {
+		return nil, fmt.Errorf("No source defined.")
+	}
+
+	dir, exists, err := vs.DirExists()
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err = os.MkdirAll(dir, 0777); err != nil {
+			return nil, errwrap.Wrap(err, "Create versions directory.")
+		}
+	}
+
+	target := filepath.Join(dir, name)
+	if _, err = os.Lstat(target); err == nil {
+		return nil, fmt.Errorf("GoLang version %q has be installed.", name)
+	} else if !os.IsNotExist(err) {
+		return nil, errwrap.Wrap(err, "Stat of %q", target)
+	}
+
+	bootstrap, err := vs.bootstrap(options.Bootstrap)
+	if err != nil {
+		return nil, err
+	}
+
+	logPath := vs.BuildLogPath(name)
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return nil, errwrap.Wrap(err, "Create log file")
+	}
+	defer logFile.Close()
+	var log io.Writer = logFile
+	if options.Log != nil {
+		log = io.MultiWriter(logFile, options.Log)
+	}
+
+	tmpDir, err := vs.Env.TempDir()
+	if err != nil {
+		return nil, err
+	}
+	buildDir, err := ioutil.TempDir(tmpDir, "build-"+name+"-")
+	if err != nil {
+		return nil, errwrap.Wrap(err, "Create build directory")
+	}
+	defer os.RemoveAll(buildDir)
+
+	root := filepath.Join(buildDir, "go")
+	if err = vs.buildSource(ctx, name, root, options, log); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, errwrap.Wrap(err, "Get sources (see %q)", logPath)
+	}
+
+	fmt.Fprintf(log, "$ GOROOT_BOOTSTRAP=%s ./make.bash\n", bootstrap.Root)
+	cmd := exec.CommandContext(ctx, "bash", "make.bash")
+	cmd.Dir = filepath.Join(root, "src")
+	cmd.Stdout, cmd.Stderr = log, log
+	cmd.Env = buildEnv("GOROOT_BOOTSTRAP=" + bootstrap.Root)
+	if err = cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, errwrap.Wrap(err, "make.bash (see %q)", logPath)
+	}
+
+	if err = os.Rename(root, target); err != nil {
+		return nil, errwrap.Wrap(err, "Move %q to %q", root, target)
+	}
+	if version, err = NewGoVersion(target); err != nil {
+		os.RemoveAll(target)
+		return nil, err
+	}
+	version.Name = name
+	version.versions = vs
+	return version, nil
+}
+
+func (vs *GoVersions) bootstrap(name string) (*GoVersion, error) {
+	if name == "" || name == "sys" {
+		system, err := GetSystemGoVersion()
+		if err != nil {
+			return nil, err
+		}
+		if system == nil {
+			return nil, fmt.Errorf("GO isn't available on system for bootstrap.")
+		}
+		return system, nil
+	}
+	version, err := vs.Get(strings.ToLower(name))
+	if err != nil {
+		return nil, err
+	}
+	if version == nil {
+		return nil, fmt.Errorf("Bootstrap version %q has not be installed", name)
+	}
+	return version, nil
+}
+
+func (vs *GoVersions) buildSource(ctx context.Context, name, root string, options *BuildOptions, log io.Writer) error {
+	if options.GitRef == "" {
+		fmt.Fprintf(log, "Copy %q to %q\n", options.SourceDir, root)
+		if err := copyTree(ctx, options.SourceDir, root); err != nil {
+			return err
+		}
+		for _, f := range []string{"VERSION", ".git"} {
+			if _, err := os.Stat(filepath.Join(root, f)); err == nil {
+				return nil
+			}
+		}
+		// make.bash requires the VERSION file or git repository.
+		if !strings.HasPrefix(name, "go") {
+			name = "devel " + name
+		}
+		return ioutil.WriteFile(filepath.Join(root, "VERSION"), []byte(name), 0644)
+	}
+
+	url := options.GitURL
+	if options.SourceDir != "" {
+		url = options.SourceDir
+	} else if url == "" {
+		url = GO_SOURCE_REPOSITORY
+	}
+	for _, args := range [][]string{
+		{"init", "-q", root},
+		{"-C", root, "fetch", "--depth", "1", url, options.GitRef},
+		{"-C", root, "checkout", "-q", "FETCH_HEAD"},
+	} {
+		fmt.Fprintf(log, "$ git %s\n", strings.Join(args, " "))
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Stdout, cmd.Stderr = log, log
+		if err := cmd.Run(); err != nil {
+			return errwrap.Wrap(err, "git %s", args[len(args)-1])
+		}
+	}
+	return nil
+}
+
+// buildEnv returns the current enviroment without Go variables that affects
+// the bootstrap.
+func buildEnv(values ...string) (env []string) {
+	for _, v := range os.Environ() {
+		switch strings.SplitN(v, "=", 2)[0] {
+		case "GOROOT", "GOBIN", "GOFLAGS", "GOTOOLCHAIN", "GOROOT_BOOTSTRAP":
+			continue
+		}
+		env = append(env, v)
+	}
+	return append(append(env, "GOTOOLCHAIN=local"), values...)
+}
+
+// copyTree copies the directory tree src to dst, preserving the modes and
+// symbolic links.
+func copyTree(ctx context.Context, src, dst string) error {
+	return filepath.Walk(src, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errwrap.Wrap(err, "Walk %q", pth)
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, pth)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(pth)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(pth, target, info.Mode())
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return errwrap.Wrap(err, "Copy %q", src)
+	}
+	return out.Close()
+}
@@ -0,0 +1,344 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/moisespsena-go/error-wrap"
+)
+
+const (
+	// CACHE_BASENAME is the database dir of shared caches.
+	CACHE_BASENAME = ".cache"
+	// MODCACHE_SETTINGS_NAME is the file, in SETTINGS_DIR, of enviroment
+	// module cache mode.
+	MODCACHE_SETTINGS_NAME = "modcache"
+
+	// MODCACHE_ISOLATED uses $GOPATH/pkg/mod of enviroment.
+	MODCACHE_ISOLATED = "isolated"
+	// MODCACHE_SHARED uses $GOENVROOT/.cache/mod, exported as GOMODCACHE by
+	// activate script.
+	MODCACHE_SHARED = "shared"
+)
+
+// SharedModCacheDir returns the shared module cache dir.
+func (env *GoEnv) SharedModCacheDir() string {
+	return filepath.Join(env.DbDir, CACHE_BASENAME, "mod")
+}
+
+func checkModCacheMode(mode string) error {
+	if mode != MODCACHE_ISOLATED && mode != MODCACHE_SHARED {
+		return fmt.Errorf("Invalid module cache mode %q. Use %s or %s.", mode, MODCACHE_ISOLATED, MODCACHE_SHARED)
+	}
+	return nil
+}
+
+// ModCacheMode returns the module cache mode of enviroment. Defaults to
+// cache.mode config.
+func (env *GoEnv) ModCacheMode(name string) (string, error) {
+	pth, err := env.GetCheck(name)
+	if err != nil {
+		return "", err
+	}
+	return env.modCacheMode(pth)
+}
+
+func (env *GoEnv) modCacheMode(pth string) (mode string, err error) {
+	lines, err := readSettings(pth, MODCACHE_SETTINGS_NAME)
+	if err != nil {
+		return "", err
+	}
+	if len(lines) > 0 {
+		mode = lines[0]
+	} else {
+		mode = env.Config.String("cache.mode")
+	}
+	if err = checkModCacheMode(mode); err != nil {
+		return "", errwrap.Wrap(err, "Enviroment %q", filepath.Base(pth))
+	}
+	return
+}
+
+// SetModCacheMode saves the module cache mode of enviroment and regenerates
+// the activate script. The mode is one of MODCACHE_ISOLATED or
+// MODCACHE_SHARED. The existing caches aren't moved (see MigrateModCache).
+func (env *GoEnv) SetModCacheMode(name, mode string) error {
+	env, unlock, err := env.LockEnv(false, name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := checkModCacheMode(mode); err != nil {
+		return err
+	}
+	pth, err := env.GetCheck(name)
+	if err != nil {
+		return err
+	}
+	if err = writeSettings(pth, MODCACHE_SETTINGS_NAME, mode); err != nil {
+		return err
+	}
+	_, err = env.Update(name)
+	return err
+}
+
+// ModCacheDir returns the module cache dir used by enviroment.
+func (env *GoEnv) ModCacheDir(name string) (string, error) {
+	pth, err := env.GetCheck(name)
+	if err != nil {
+		return "", err
+	}
+	mode, err := env.modCacheMode(pth)
+	if err != nil {
+		return "", err
+	}
+	if mode == MODCACHE_SHARED {
+		return env.SharedModCacheDir(), nil
+	}
+	return filepath.Join(pth, "pkg", "mod"), nil
+}
+
+// ModCacheUsage is the disk usage of module cache.
+type ModCacheUsage struct {
+	// Name is the enviroment name, or empty for shared cache.
+	Name string
+	// Mode is the module cache mode of enviroment.
+	Mode  string
+	Dir   string
+	Size  int64
+	Files int
+}
+
+// ModCacheUsage returns the disk usage of shared module cache (first) and
+// of isolated caches of enviroments. If names is empty, all enviroments are
+// reported.
+func (env *GoEnv) ModCacheUsage(names ...string) (usage []*ModCacheUsage, err error) {
+	if len(names) == 0 {
+		if names, err = env.Ls(); err != nil {
+			return nil, err
+		}
+	}
+	shared := &ModCacheUsage{Mode: MODCACHE_SHARED, Dir: env.SharedModCacheDir()}
+	if shared.Size, shared.Files, err = dirSize(shared.Dir); err != nil {
+		return nil, err
+	}
+	usage = append(usage, shared)
+	for _, name := range names {
+		pth, err := env.GetCheck(name)
+		if err != nil {
+			return nil, err
+		}
+		u := &ModCacheUsage{Name: name, Dir: filepath.Join(pth, "pkg", "mod")}
+		if u.Mode, err = env.modCacheMode(pth); err != nil {
+			return nil, err
+		}
+		if u.Size, u.Files, err = dirSize(u.Dir); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return
+}
+
+func dirSize(dir string) (size int64, files int, err error) {
+	if ok, err := IsDir(dir); err != nil || !ok {
+		return 0, 0, err
+	}
+	if size, files, err = scanSize(dir, nil); err != nil {
+		err = errwrap.Wrap(err, "Scan %q", dir)
+	}
+	return
+}
+
+// CleanModCache removes the isolated module caches of enviroments. If
+// shared is true, the shared cache is also removed.
+func (env *GoEnv) CleanModCache(shared bool, names ...string) (removed int64, err error) {
+	env, unlock, err := env.LockEnv(false, names...)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	if shared {
+		_, unlockCache, err := env.lock(LOCK_CACHE, false)
+		if err != nil {
+			return 0, err
+		}
+		defer unlockCache()
+	}
+
+	var dirs []string
+	for _, name := range names {
+		pth, err := env.GetCheck(name)
+		if err != nil {
+			return 0, err
+		}
+		dirs = append(dirs, filepath.Join(pth, "pkg", "mod"))
+	}
+	if shared {
+		dirs = append(dirs, env.SharedModCacheDir())
+	}
+	for _, dir := range dirs {
+		size, _, err := dirSize(dir)
+		if err != nil {
+			return removed, err
+		}
+		if err = removeModCache(dir); err != nil {
+			return removed, err
+		}
+		removed += size
+	}
+	return
+}
+
+// removeModCache removes the module cache dir. The go command makes the
+// module dirs read-only, so the dirs are made writable first.
+func removeModCache(dir string) error {
+	if ok, err := IsDir(dir); err != nil || !ok {
+		return err
+	}
+	if err := makeWritable(dir); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return errwrap.Wrap(err, "Remove %q", dir)
+	}
+	return nil
+}
+
+func makeWritable(dir string) error {
+	return filepath.Walk(dir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Mode().Perm()&0200 == 0 {
+			return os.Chmod(pth, info.Mode().Perm()|0200)
+		}
+		return nil
+	})
+}
+
+// ModCacheMigration is the result of MigrateModCache.
+type ModCacheMigration struct {
+	Envs []string
+	// Moved is the size of files moved to shared cache.
+	Moved int64
+	// Deduplicated is the size of files removed because already exists into
+	// shared cache.
+	Deduplicated int64
+}
+
+// MigrateModCache moves the isolated module caches of enviroments into the
+// shared cache, removing the files already cached, and sets the enviroments
+// mode to MODCACHE_SHARED. The module contents are immutable, so files with
+// same path are equals.
+func (env *GoEnv) MigrateModCache(names ...string) (result *ModCacheMigration, err error) {
+	env, unlock, err := env.LockEnv(false, names...)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	_, unlockCache, err := env.lock(LOCK_CACHE, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlockCache()
+
+	shared := env.SharedModCacheDir()
+	if err = MkdirAll(shared); err != nil {
+		return nil, err
+	}
+	result = &ModCacheMigration{}
+	for _, name := range names {
+		pth, err := env.GetCheck(name)
+		if err != nil {
+			return result, err
+		}
+		dir := filepath.Join(pth, "pkg", "mod")
+		if ok, err := IsDir(dir); err != nil {
+			return result, err
+		} else if ok {
+			if err = mergeModCache(dir, shared, result); err != nil {
+				return result, fmt.Errorf("Migrate %q: %w", name, err)
+			}
+		}
+		if err = env.SetModCacheMode(name, MODCACHE_SHARED); err != nil {
+			return result, err
+		}
+		result.Envs = append(result.Envs, name)
+	}
+	return
+}
+
+// mergeModCache moves the entries of src not found into dst, and removes
+// src.
+func mergeModCache(src, dst string, result *ModCacheMigration) error {
+	if err := makeWritable(src); err != nil {
+		return err
+	}
+	err := filepath.Walk(src, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, pth)
+		if err != nil || rel == "." {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if _, err := os.Lstat(target); err == nil {
+			if !info.IsDir() {
+				result.Deduplicated += info.Size()
+			}
+			return nil
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		size := info.Size()
+		if info.IsDir() {
+			if size, _, err = scanSize(pth, nil); err != nil {
+				return err
+			}
+		}
+		if err = os.Rename(pth, target); err != nil {
+			return errwrap.Wrap(err, "Move %q", rel)
+		}
+		result.Moved += size
+		if info.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return removeModCache(src)
+}
+
+// modCacheActivate returns the activate script lines of module cache mode
+// of enviroment dir pth. The isolated mode unsets the GOMODCACHE of shell, so
+// the default $GOPATH/pkg/mod is used.
+func (env *GoEnv) modCacheActivate(pth string) (string, error) {
+	mode, err := env.modCacheMode(pth)
+	if err != nil {
+		return "", err
+	}
+	if mode != MODCACHE_SHARED {
+		return "unset GOMODCACHE\n", nil
+	}
+	return "export GOMODCACHE=\"$GOENVROOT/" + CACHE_BASENAME + "/mod\"\n", nil
+}
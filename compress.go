@@ -16,9 +16,14 @@ package goenv
 
 import (
 	"archive/tar"
+	"bufio"
 	"compress/gzip"
+	"context"
+	"crypto/sha256"
 	"fmt"
+	"hash"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -53,20 +58,50 @@ type Header struct {
 	OS      byte      // operating system type
 }
 
-func compress(source string, writer io.Writer, exclude ValidFunc) error {
+const (
+	// BACKUP_CODEC_GZIP is the gzip compressed tar archive codec.
+	BACKUP_CODEC_GZIP = "gzip"
+	// BACKUP_CODEC_TAR is the uncompressed tar archive codec.
+	BACKUP_CODEC_TAR = "tar"
+)
+
+// BackupCodecExt returns the file extension of backup codec.
+func BackupCodecExt(codec string) (string, error) {
+	switch codec {
+	case "", BACKUP_CODEC_GZIP:
+		return ".tar.gz", nil
+	case BACKUP_CODEC_TAR:
+		return ".tar", nil
+	}
+	return "", fmt.Errorf("Invalid backup codec %q.", codec)
+}
+
+// compress writes the archive of source. If manifest is defined, it's the
+// first entry and the contents of files are checked with its hashes while
+// archived, so the files changed during backup fails.
+func compress(ctx context.Context, source string, writer io.Writer, codec string, exclude ValidFunc, manifest *BackupManifest, progress *progressTracker) (err error) {
+	if codec != BACKUP_CODEC_TAR {
+		gzWriter := gzip.NewWriter(writer)
+		if err = writeArchive(ctx, source, gzWriter, exclude, manifest, progress); err != nil {
+			gzWriter.Close()
+			return
+		}
+		return errwrap.Wrap(gzWriter.Close(), "Close gzip writer")
+	}
+	return writeArchive(ctx, source, writer, exclude, manifest, progress)
+}
+
+func writeArchive(ctx context.Context, source string, writer io.Writer, exclude ValidFunc, manifest *BackupManifest, progress *progressTracker) (err error) {
 	if exclude == nil {
 		exclude = func(pth string, info os.FileInfo) bool {
 			return false
 		}
 	}
-	gzWriter := gzip.NewWriter(writer)
-	tarWriter := tar.NewWriter(gzWriter)
-	defer tarWriter.Close()
-	defer gzWriter.Close()
+	tarWriter := tar.NewWriter(writer)
 
 	info, err := os.Stat(source)
 	if err != nil {
-		return nil
+		return err
 	}
 
 	var baseDir string
@@ -74,11 +109,56 @@ func compress(source string, writer io.Writer, exclude ValidFunc) error {
 		baseDir = filepath.Base(source)
 	}
 
-	return filepath.Walk(source,
+	headerName := func(path string, info os.FileInfo) string {
+		if baseDir != "" {
+			return filepath.Join(baseDir, strings.TrimPrefix(path, source))
+		}
+		return info.Name()
+	}
+
+	if manifest != nil {
+		err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return errwrap.Wrap(err, "Start: %v", path)
+			}
+			if err = ctx.Err(); err != nil {
+				return err
+			}
+			if exclude(path, info) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			return manifest.AddFile(headerName(path, info), path)
+		})
+		if err != nil {
+			return err
+		}
+		if err = manifest.writeTo(tarWriter); err != nil {
+			return err
+		}
+		progress.setTotal(manifest.Size, len(manifest.Files))
+	} else if progress != nil {
+		size, files, err := scanSize(source, exclude)
+		if err != nil {
+			return errwrap.Wrap(err, "Scan %v", source)
+		}
+		progress.setTotal(size, files)
+	}
+	defer progress.done()
+
+	err = filepath.Walk(source,
 		func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				return errwrap.Wrap(err, "Start: %v", path)
 			}
+			if err = ctx.Err(); err != nil {
+				return err
+			}
 			if exclude(path, info) {
 				if info.IsDir() {
 					return filepath.SkipDir
@@ -90,9 +170,7 @@ func compress(source string, writer io.Writer, exclude ValidFunc) error {
 				return errwrap.Wrap(err, "Get FileInfoHeader: %v", path)
 			}
 
-			if baseDir != "" {
-				header.Name = filepath.Join(baseDir, strings.TrimPrefix(path, source))
-			}
+			header.Name = headerName(path, info)
 
 			if err := tarWriter.WriteHeader(header); err != nil {
 				return errwrap.Wrap(err, "Write Header: %v", path)
@@ -102,36 +180,61 @@ func compress(source string, writer io.Writer, exclude ValidFunc) error {
 				return nil
 			}
 
+			if info.Mode().IsRegular() {
+				progress.file(path)
+			}
+
 			stat, err := os.Stat(path)
 			if err != nil {
 				return errwrap.Wrap(err, "Stat of %v", path)
 			}
 
-			if stat.Size() == 0 {
-				return nil
-			}
-
-			file, err := os.Open(path)
-			if err != nil {
-				return errwrap.Wrap(err, "Open: %v", path)
+			h := sha256.New()
+			if stat.Size() > 0 {
+				file, err := os.Open(path)
+				if err != nil {
+					return errwrap.Wrap(err, "Open: %v", path)
+				}
+				defer file.Close()
+				_, err = io.Copy(io.MultiWriter(progress.writer(tarWriter), h), &ctxReader{ctx, file})
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if err != nil {
+					return errwrap.Wrap(err, "Copy: %v [%s]", path, humanize.Bytes(uint64(stat.Size())))
+				}
 			}
-
-			if filepath.Base(path) == "acorn" {
-				println()
+			if manifest != nil && info.Mode().IsRegular() {
+				if err = manifest.checkFile(header.Name, h); err != nil {
+					return errwrap.Wrap(err, "File %q changed during backup", path)
+				}
 			}
-
-			defer file.Close()
-			_, err = io.Copy(tarWriter, file)
-			return errwrap.Wrap(err, "Copy: %v [%s]", path, humanize.Bytes(uint64(stat.Size())))
+			return nil
 		})
+	if err != nil {
+		return err
+	}
+	return errwrap.Wrap(tarWriter.Close(), "Close tar writer")
 }
 
 type BackupFile struct {
-	Reader *tar.Reader
+	Reader   *tar.Reader
+	Manifest *BackupManifest
+	Progress ProgressFunc
+	// Logger receives the extracted entries in verbose mode.
+	Logger Logger
 	first  *tar.Header
 }
 
+// NewBackupReader returns the reader of backup file. If archive is false and
+// the reader is gzip compressed, it is decompressed.
 func NewBackupReader(reader io.Reader, archive bool) (bkp *BackupFile, err error) {
+	if !archive {
+		r := bufio.NewReader(reader)
+		magic, _ := r.Peek(2)
+		reader = r
+		archive = len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b
+	}
 	if !archive {
 		reader, err = gzip.NewReader(reader)
 		if err != nil {
@@ -146,7 +249,11 @@ func (b *BackupFile) GetRootName() (name string, err error) {
 	if b.first != nil {
 		return b.first.Name, nil
 	}
-	err = b.Each(func(header *tar.Header, reader *tar.Reader) error {
+	err = b.Each(func(header *tar.Header, reader *tar.Reader) (err error) {
+		if header.Name == MANIFEST_NAME && b.Manifest == nil {
+			b.Manifest, err = readBackupManifest(reader)
+			return
+		}
 		b.first = header
 		return io.EOF
 	})
@@ -155,6 +262,10 @@ func (b *BackupFile) GetRootName() (name string, err error) {
 		return "", err
 	}
 
+	if b.first == nil {
+		return "", fmt.Errorf("Empty backup.")
+	}
+
 	name = strings.Trim(b.first.Name, string(os.PathSeparator))
 	if !b.first.FileInfo().IsDir() || strings.Contains(name, string(os.PathSeparator)) {
 		return "", fmt.Errorf("Invalid root name %q", name)
@@ -236,34 +347,57 @@ var typeDesc = map[byte]string{
 }
 
 func (b *BackupFile) Extract(rootName, target string, options ExtractOptions) error {
+	return b.ExtractContext(context.Background(), rootName, target, options)
+}
+
+// ExtractContext is like Extract, but stops when ctx is done. The entries
+// already extracted aren't removed. If the backup has manifest, the contents
+// of files are checked with its hashes.
+func (b *BackupFile) ExtractContext(ctx context.Context, rootName, target string, options ExtractOptions) error {
+	progress := newProgressTracker("restore", b.Progress)
+	if progress != nil && b.Manifest != nil {
+		progress.setTotal(b.Manifest.Size, len(b.Manifest.Files))
+	}
+	defer progress.done()
+
+	originalRootName, err := b.GetRootName()
+	if err != nil {
+		return err
+	}
+	if rootName == "" {
+		rootName = originalRootName
+	}
+	// manifestName returns the name registered into manifest.
+	manifestName := func(name string) string {
+		return filepath.Join(originalRootName, strings.TrimPrefix(name, rootName))
+	}
+	check := func(name string, h hash.Hash) error {
+		if b.Manifest == nil {
+			return nil
+		}
+		return b.Manifest.checkFile(manifestName(name), h)
+	}
+
 	return b.EachRoot(rootName, func(header *tar.Header, reader *tar.Reader) (err error) {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
 		info := header.FileInfo()
-		if options.IsVerbose() {
-			prefix := pad(typeDesc[header.Typeflag], 5) + " "
+		if options.IsVerbose() && b.Logger != nil {
+			e := &Event{Type: EventExtractEntry, Kind: typeDesc[header.Typeflag], Path: header.Name, Bytes: -1}
 			switch header.Typeflag {
 			case tar.TypeReg, tar.TypeRegA:
-				prefix += pad("["+humanize.Bytes(uint64(info.Size()))+"]", 12)
-			default:
-				prefix += pad("", 12)
-			}
-			name := header.Name
-			switch header.Typeflag {
+				e.Bytes = info.Size()
 			case tar.TypeLink, tar.TypeSymlink:
-				name += " -> " + header.Linkname
+				e.Path += " -> " + header.Linkname
 			}
-			os.Stdout.WriteString(prefix + name + "... ")
+			defer func() {
+				e.Err = err
+				b.Logger.Event(e)
+			}()
 		}
 		path := filepath.Join(target, header.Name)
 		if info.IsDir() {
-			if options.IsVerbose() {
-				defer func() {
-					if err != nil {
-						os.Stdout.WriteString("failed.\n")
-					} else {
-						os.Stdout.WriteString(":done.\n")
-					}
-				}()
-			}
 			if options.IsTrial() {
 				return nil
 			}
@@ -273,8 +407,18 @@ func (b *BackupFile) Extract(rootName, target string, options ExtractOptions) er
 			return nil
 		}
 
+		if info.Mode().IsRegular() {
+			progress.file(path)
+		}
+
+		h := sha256.New()
 		if options.IsTrial() {
-			os.Stdout.WriteString("done.\n")
+			if _, err = io.Copy(io.MultiWriter(progress.writer(ioutil.Discard), h), &ctxReader{ctx, reader}); err != nil {
+				return
+			}
+			if info.Mode().IsRegular() {
+				return check(header.Name, h)
+			}
 			return
 		}
 
@@ -289,16 +433,31 @@ func (b *BackupFile) Extract(rootName, target string, options ExtractOptions) er
 			} else {
 				file.Close()
 			}
-			if options.IsVerbose() {
-				if err != nil {
-					os.Stdout.WriteString("failed.\n")
-				} else {
-					os.Stdout.WriteString("done.\n")
-				}
-			}
 		}()
-		_, err = io.Copy(file, reader)
-
-		return errwrap.Wrap(err, path)
+		_, err = io.Copy(io.MultiWriter(progress.writer(file), h), &ctxReader{ctx, reader})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return errwrap.Wrap(err, path)
+		}
+		if info.Mode().IsRegular() {
+			return check(header.Name, h)
+		}
+		return nil
 	})
 }
+
+// ctxReader fails the reads after ctx is done, so long copies are stopped
+// between chunks.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
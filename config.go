@@ -0,0 +1,351 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mitchellh/go-homedir"
+	"github.com/moisespsena-go/error-wrap"
+)
+
+// CONFIG_NAME is the config file name of database and of XDG config dir.
+const CONFIG_NAME = "config.toml"
+
+// SystemConfigPath is the system-wide config file.
+var SystemConfigPath = "/etc/goenv/" + CONFIG_NAME
+
+// UserConfigPath returns the user config file in XDG config dir.
+func UserConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "goenv", CONFIG_NAME)
+}
+
+type ConfigType int
+
+const (
+	ConfigString ConfigType = iota
+	ConfigBool
+	ConfigInt
+	ConfigDuration
+	ConfigList
+)
+
+// ConfigKey is a known config key.
+type ConfigKey struct {
+	Name        string
+	Type        ConfigType
+	Default     string
+	Description string
+	// Env are the enviroment variables that overrides the config files, in
+	// priority order.
+	Env []string
+}
+
+// ConfigKeys are the known config keys.
+var ConfigKeys = []*ConfigKey{
+	{Name: "db", Default: "~/.goenv", Env: []string{"GOENVDB"},
+		Description: "Default database directory. Used only from system and user config."},
+	{Name: "go.default",
+		Description: "Go version of new enviroments. Empty uses the system Go."},
+	{Name: "prompt.format", Default: "[go:$GOENVNAME] ",
+		Description: "Prefix of PS1 of active enviroment."},
+	{Name: "activate.auto_update", Type: ConfigBool, Default: "false", Env: []string{"GOENV_AUTO_UPDATE"},
+		Description: "Regenerate the outdated activate scripts on activation."},
+	{Name: "backup.codec", Default: BACKUP_CODEC_GZIP,
+		Description: "Backup archive codec: gzip or tar (uncompressed)."},
+	{Name: "backup.retention", Type: ConfigInt, Default: "0",
+		Description: "Number of default backups kept per enviroment. Zero keeps all."},
+	{Name: "backup.exclude", Type: ConfigList,
+		Description: "Default backup exclude patterns."},
+	{Name: "versions.mirrors", Type: ConfigList, Env: []string{"GOENV_GO_MIRROR"},
+		Description: "Base URLs of mirrors used to download Go versions."},
+	{Name: "cache.mode", Default: MODCACHE_ISOLATED,
+		Description: "Module cache of enviroments without modcache setting: isolated ($GOPATH/pkg/mod) or shared ($GOENVROOT/.cache/mod)."},
+	{Name: "lock.timeout", Type: ConfigDuration, Default: "30s",
+		Description: "Maximum wait of locks held by other goenv processes. Zero fails if held and negative waits forever."},
+	{Name: "trash.retention", Type: ConfigDuration, Default: "0",
+		Description: "Age of removed enviroments purged from trash (as 720h or 30d). Zero keeps all."},
+}
+
+// GetConfigKey returns the known config key, or nil.
+func GetConfigKey(name string) *ConfigKey {
+	for _, key := range ConfigKeys {
+		if key.Name == name {
+			return key
+		}
+	}
+	return nil
+}
+
+// EnvName returns the default enviroment variable of key, as GOENV_GO_DEFAULT
+// for "go.default".
+func (k *ConfigKey) EnvName() string {
+	return "GOENV_" + strings.ToUpper(strings.NewReplacer(".", "_").Replace(k.Name))
+}
+
+// Parse validates and normalizes the value.
+func (k *ConfigKey) Parse(value string) (interface{}, error) {
+	switch k.Type {
+	case ConfigBool:
+		return strconv.ParseBool(value)
+	case ConfigInt:
+		return strconv.Atoi(value)
+	case ConfigDuration:
+		if _, err := parseDuration(value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	case ConfigList:
+		return splitList(value), nil
+	}
+	return value, nil
+}
+
+// splitList splits the comma separated list. The items are trimmed, so the
+// items may have inner spaces.
+func splitList(value string) (items []string) {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return
+}
+
+// parseDuration parses time.Duration values, also accepting the "d" (days)
+// unit, as "30d".
+func parseDuration(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("Invalid duration %q.", value)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// ConfigFile is a config layer loaded from TOML file.
+type ConfigFile struct {
+	Name   string
+	Path   string
+	values map[string]interface{}
+}
+
+// LoadConfigFile loads the config file. If it doesn't exists, returns an empty
+// config.
+func LoadConfigFile(name, pth string) (*ConfigFile, error) {
+	f := &ConfigFile{Name: name, Path: pth, values: map[string]interface{}{}}
+	if pth == "" {
+		return f, nil
+	}
+	if _, err := toml.DecodeFile(pth, &f.values); err != nil && !os.IsNotExist(err) {
+		return nil, errwrap.Wrap(err, "Load config %q", pth)
+	}
+	return f, nil
+}
+
+// Get returns the value of dotted key.
+func (f *ConfigFile) Get(key string) (value interface{}, ok bool) {
+	m := f.values
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		if m, ok = m[part].(map[string]interface{}); !ok {
+			return nil, false
+		}
+	}
+	value, ok = m[parts[len(parts)-1]]
+	return
+}
+
+// Set sets (or removes if value is nil) the value of dotted key.
+func (f *ConfigFile) Set(key string, value interface{}) {
+	setConfigValue(f.values, strings.Split(key, "."), value)
+}
+
+func setConfigValue(m map[string]interface{}, parts []string, value interface{}) {
+	if len(parts) == 1 {
+		if value == nil {
+			delete(m, parts[0])
+		} else {
+			m[parts[0]] = value
+		}
+		return
+	}
+	child, ok := m[parts[0]].(map[string]interface{})
+	if !ok {
+		if value == nil {
+			return
+		}
+		child = map[string]interface{}{}
+		m[parts[0]] = child
+	}
+	setConfigValue(child, parts[1:], value)
+	if len(child) == 0 {
+		delete(m, parts[0])
+	}
+}
+
+// Save writes the config file.
+func (f *ConfigFile) Save() error {
+	pruneConfigValues(f.values)
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(f.values); err != nil {
+		return errwrap.Wrap(err, "Encode config")
+	}
+	if err := MkdirAll(filepath.Dir(f.Path)); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(f.Path, buf.Bytes(), 0644); err != nil {
+		return errwrap.Wrap(err, "Write %q", f.Path)
+	}
+	return nil
+}
+
+// pruneConfigValues removes the empty tables.
+func pruneConfigValues(m map[string]interface{}) {
+	for key, value := range m {
+		if child, ok := value.(map[string]interface{}); ok {
+			if pruneConfigValues(child); len(child) == 0 {
+				delete(m, key)
+			}
+		}
+	}
+}
+
+// Config is the layered configuration. The layers are, in priority order:
+// the enviroment variables, the database config ($DB/config.toml), the user
+// config ($XDG_CONFIG_HOME/goenv/config.toml) and the system config
+// (/etc/goenv/config.toml). The command line flags overrides the config.
+type Config struct {
+	// Files are the config files, from lowest to highest priority.
+	Files []*ConfigFile
+}
+
+// LoadConfig loads the system, user and database configs. If dbDir is empty,
+// the database config isn't loaded.
+func LoadConfig(dbDir string) (config *Config, err error) {
+	config = &Config{}
+	layers := [][2]string{{"system", SystemConfigPath}, {"user", UserConfigPath()}}
+	if dbDir != "" {
+		layers = append(layers, [2]string{"db", filepath.Join(dbDir, CONFIG_NAME)})
+	}
+	for _, layer := range layers {
+		f, err := LoadConfigFile(layer[0], layer[1])
+		if err != nil {
+			return nil, err
+		}
+		config.Files = append(config.Files, f)
+	}
+	return
+}
+
+// File returns the config file of layer name, or nil.
+func (c *Config) File(name string) *ConfigFile {
+	if c != nil {
+		for _, f := range c.Files {
+			if f.Name == name {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup returns the value of key and its source: the enviroment variable
+// name, the config file layer name, or "default".
+func (c *Config) Lookup(name string) (value, source string) {
+	key := GetConfigKey(name)
+	if key == nil {
+		return "", ""
+	}
+	for _, env := range append(append([]string{}, key.Env...), key.EnvName()) {
+		if value, ok := os.LookupEnv(env); ok && value != "" {
+			return value, "env " + env
+		}
+	}
+	if c != nil {
+		for i := len(c.Files) - 1; i >= 0; i-- {
+			if v, ok := c.Files[i].Get(name); ok {
+				return formatConfigValue(v), c.Files[i].Name
+			}
+		}
+	}
+	return key.Default, "default"
+}
+
+func formatConfigValue(v interface{}) string {
+	switch t := v.(type) {
+	case []interface{}:
+		var values []string
+		for _, item := range t {
+			values = append(values, fmt.Sprint(item))
+		}
+		return strings.Join(values, ",")
+	case []string:
+		return strings.Join(t, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+func (c *Config) String(name string) string {
+	value, _ := c.Lookup(name)
+	return value
+}
+
+func (c *Config) Strings(name string) []string {
+	return splitList(c.String(name))
+}
+
+func (c *Config) Bool(name string) bool {
+	value, _ := strconv.ParseBool(c.String(name))
+	return value
+}
+
+func (c *Config) Int(name string) int {
+	value, _ := strconv.Atoi(c.String(name))
+	return value
+}
+
+func (c *Config) Duration(name string) time.Duration {
+	value, _ := parseDuration(c.String(name))
+	return value
+}
+
+// DefaultDB returns the database directory from GOENVDB enviroment variable,
+// user or system config.
+func DefaultDB() (string, error) {
+	config, err := LoadConfig("")
+	if err != nil {
+		return "", err
+	}
+	return config.String("db"), nil
+}
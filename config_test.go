@@ -0,0 +1,34 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitList(t *testing.T) {
+	for value, want := range map[string][]string{
+		"":                            nil,
+		"a":                           {"a"},
+		" a , b,,c ":                  {"a", "b", "c"},
+		"vendor/my dir, *.log":        {"vendor/my dir", "*.log"},
+		"https://m1/go,https://m2/go": {"https://m1/go", "https://m2/go"},
+	} {
+		if got := splitList(value); !reflect.DeepEqual(got, want) {
+			t.Errorf("splitList(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/moisespsena-go/error-wrap"
+)
+
+const (
+	// DB_FORMAT_NAME is the file, on database, of layout format version.
+	DB_FORMAT_NAME = ".format"
+	// DB_FORMAT is the layout format version of this release. The databases
+	// created before the format file are of format 0.
+	DB_FORMAT = 1
+)
+
+// DBLayoutDirs are the dirs created by InitDB.
+var DBLayoutDirs = []string{VERSIONS_BASENAME, ".backup", ".trash", ".tmp", LOCKS_BASENAME}
+
+// DBMigration upgrades the database layout to Format.
+type DBMigration struct {
+	Format      int
+	Description string
+	Migrate     func(env *GoEnv) error
+}
+
+// DBMigrations are the migrations run by MigrateDB, in order.
+var DBMigrations = []*DBMigration{
+	{
+		Format:      1,
+		Description: "write the Go version binding of enviroments",
+		Migrate:     migrateBindings,
+	},
+}
+
+// ReadDBFormat returns the layout format version of database. If the format
+// file doesn't exists, returns 0.
+func ReadDBFormat(dbDir string) (format int, err error) {
+	pth := filepath.Join(dbDir, DB_FORMAT_NAME)
+	data, err := ioutil.ReadFile(pth)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, errwrap.Wrap(err, "Read %q", pth)
+	}
+	if format, err = strconv.Atoi(strings.TrimSpace(string(data))); err != nil {
+		return 0, errwrap.Wrap(err, "Parse %q", pth)
+	}
+	return
+}
+
+func writeDBFormat(dbDir string, format int) error {
+	pth := filepath.Join(dbDir, DB_FORMAT_NAME)
+	if err := ioutil.WriteFile(pth, []byte(strconv.Itoa(format)+"\n"), 0644); err != nil {
+		return errwrap.Wrap(err, "Write %q", pth)
+	}
+	return nil
+}
+
+// CheckDBFormat checks the layout format of database. If the format is newer
+// than DB_FORMAT, returns *DBError of ErrDBFormat. If the database exists with
+// older format, outdated is true. The missing database isn't checked.
+func CheckDBFormat(dbDir string) (outdated bool, err error) {
+	ok, err := IsDir(dbDir)
+	if err != nil || !ok {
+		return false, err
+	}
+	format, err := ReadDBFormat(dbDir)
+	if err != nil {
+		return false, err
+	}
+	if format > DB_FORMAT {
+		return false, &DBError{dbDir, ErrDBFormat}
+	}
+	return format < DB_FORMAT, nil
+}
+
+// InitDB creates the database layout and format file. If the database is
+// initialized, returns created false. If the database exists with other
+// files and without format file, returns *DBError of ErrDBOutdated: use
+// MigrateDB.
+func (env *GoEnv) InitDB() (created bool, err error) {
+	if ok, err := IsFile(env.DbDir, DB_FORMAT_NAME); err != nil || ok {
+		return false, err
+	}
+	// the locks are into database, then creates the directory before lock
+	if err = MkdirAll(env.DbDir); err != nil {
+		return false, err
+	}
+	env, unlock, err := env.LockDB(false)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	// other process can be initialized it while waiting the lock
+	if ok, err := IsFile(env.DbDir, DB_FORMAT_NAME); err != nil || ok {
+		return false, err
+	}
+	items, err := ioutil.ReadDir(env.DbDir)
+	if err != nil {
+		return false, err
+	}
+	for _, f := range items {
+		if f.Name() != CONFIG_NAME && f.Name() != LOCKS_BASENAME {
+			return false, &DBError{env.DbDir, ErrDBOutdated}
+		}
+	}
+	return true, env.createDB()
+}
+
+// createDB creates the layout of new database. The caller must hold the
+// exclusive database lock.
+func (env *GoEnv) createDB() error {
+	for _, dir := range DBLayoutDirs {
+		if err := MkdirAll(env.DbDir, dir); err != nil {
+			return err
+		}
+	}
+	return writeDBFormat(env.DbDir, DB_FORMAT)
+}
+
+// MigrateDB runs the DBMigrations newer than the database format, saving the
+// format after each migration.
+func (env *GoEnv) MigrateDB() (applied []*DBMigration, err error) {
+	if ok, err := IsDir(env.DbDir); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, &DBError{env.DbDir, ErrDBNotInitialized}
+	}
+	env, unlock, err := env.LockDB(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	format, err := ReadDBFormat(env.DbDir)
+	if err != nil {
+		return nil, err
+	}
+	if format > DB_FORMAT {
+		return nil, &DBError{env.DbDir, ErrDBFormat}
+	}
+	for _, m := range DBMigrations {
+		if m.Format <= format {
+			continue
+		}
+		if err = m.Migrate(env); err != nil {
+			return applied, fmt.Errorf("Migrate to format %d: %w", m.Format, err)
+		}
+		if err = writeDBFormat(env.DbDir, m.Format); err != nil {
+			return applied, err
+		}
+		applied = append(applied, m)
+	}
+	for _, dir := range DBLayoutDirs {
+		if err = MkdirAll(env.DbDir, dir); err != nil {
+			return applied, err
+		}
+	}
+	return
+}
+
+// migrateBindings writes the binding file of enviroments created before it,
+// whose binding is detected from activate script.
+func migrateBindings(env *GoEnv) error {
+	names, err := env.Ls()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		pth := filepath.Join(env.DbDir, name)
+		if ok, err := IsFile(pth, SETTINGS_DIR, GOVERSION_BINDING_NAME); err != nil {
+			return err
+		} else if ok {
+			continue
+		}
+		b, err := readGoVersionBinding(pth)
+		if err != nil {
+			return fmt.Errorf("Enviroment %q: %w", name, err)
+		}
+		if err = env.BindGoVersion(name, b); err != nil {
+			return fmt.Errorf("Enviroment %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// DBInfo is the state of database.
+type DBInfo struct {
+	Dir    string `json:"dir"`
+	Format int    `json:"format"`
+	// Outdated is true if Format is older than DB_FORMAT.
+	Outdated     bool `json:"outdated"`
+	Environments int  `json:"environments"`
+	Versions     int  `json:"versions"`
+	Backups      int  `json:"backups"`
+	Trash        int  `json:"trash"`
+	Templates    int  `json:"templates"`
+	// Sizes are the disk usage of enviroments (as "environments") and of
+	// database dirs (see DiskUsageDirs).
+	Sizes map[string]int64 `json:"sizes"`
+	Total int64            `json:"total"`
+}
+
+// DBInfo returns the state of database.
+func (env *GoEnv) DBInfo() (info *DBInfo, err error) {
+	info = &DBInfo{Dir: env.DbDir, Sizes: map[string]int64{}}
+	if info.Format, err = ReadDBFormat(env.DbDir); err != nil {
+		return nil, err
+	}
+	info.Outdated = info.Format < DB_FORMAT
+
+	names, err := env.Ls()
+	if err != nil {
+		return nil, err
+	}
+	info.Environments = len(names)
+	versions, err := NewGoVersions(env).Ls()
+	if err != nil {
+		return nil, err
+	}
+	info.Versions = len(versions)
+	templates, err := env.Templates()
+	if err != nil {
+		return nil, err
+	}
+	info.Templates = len(templates)
+	if info.Trash, err = countDir(env.DbDir, ".trash"); err != nil {
+		return nil, err
+	}
+	bkpDirs, err := ioutil.ReadDir(filepath.Join(env.DbDir, ".backup"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, f := range bkpDirs {
+		if f.IsDir() {
+			n, err := countDir(env.DbDir, ".backup", f.Name())
+			if err != nil {
+				return nil, err
+			}
+			info.Backups += n
+		}
+	}
+
+	usage, err := env.DiskUsage()
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range usage {
+		info.Sizes["environments"] += u.Total
+	}
+	if usage, err = env.DBDiskUsage(); err != nil {
+		return nil, err
+	}
+	for _, u := range usage {
+		info.Sizes[u.Name] = u.Total
+	}
+	for _, size := range info.Sizes {
+		info.Total += size
+	}
+	return
+}
+
+func countDir(pth ...string) (int, error) {
+	items, err := ioutil.ReadDir(filepath.Join(pth...))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return len(items), nil
+}
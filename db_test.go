@@ -0,0 +1,75 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestInitDBConcurrent(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "db")
+	var (
+		wg      sync.WaitGroup
+		created int32
+		start   = make(chan struct{})
+	)
+	for i := 0; i < 8; i++ {
+		env, err := NewGoEnv(dir, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env.LockTimeout = -1
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			ok, err := env.InitDB()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ok {
+				atomic.AddInt32(&created, 1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	if created != 1 {
+		t.Errorf("database created %d times, want 1", created)
+	}
+	if outdated, err := CheckDBFormat(dir); err != nil || outdated {
+		t.Errorf("check format: outdated %v, err %v", outdated, err)
+	}
+}
+
+func TestInitDBOutdated(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "db")
+	writeTestFile(t, filepath.Join(dir, "e1", "src", "main.go"), "package main\n")
+	env, err := NewGoEnv(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = env.InitDB(); !errors.Is(err, ErrDBOutdated) {
+		t.Errorf("init of database without format: %v, want ErrDBOutdated", err)
+	}
+	if ok, _ := IsFile(dir, DB_FORMAT_NAME); ok {
+		t.Error("format file is created")
+	}
+}
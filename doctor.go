@@ -0,0 +1,334 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/moisespsena-go/error-wrap"
+)
+
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "INFO"
+	case SeverityWarning:
+		return "WARNING"
+	default:
+		return "ERROR"
+	}
+}
+
+// Finding is a problem reported by DoctorCheck.
+type Finding struct {
+	Check string
+	// Env is the enviroment name, or empty for database findings.
+	Env      string
+	Severity Severity
+	Message  string
+	fix      func() error
+}
+
+// Fixable reports whether the finding can be fixed by Fix.
+func (f *Finding) Fixable() bool {
+	return f.fix != nil
+}
+
+// Fix fixes the problem.
+func (f *Finding) Fix() error {
+	if f.fix == nil {
+		return fmt.Errorf("%s: isn't fixable.", f.Check)
+	}
+	return f.fix()
+}
+
+// DoctorCheck checks the enviroment, if Env is defined, or the database.
+type DoctorCheck struct {
+	Name        string
+	Description string
+	Env         func(env *GoEnv, name string) ([]*Finding, error)
+	DB          func(env *GoEnv) ([]*Finding, error)
+}
+
+// DoctorChecks are the checks run by Doctor, in order.
+var DoctorChecks = []*DoctorCheck{
+	{
+		Name:        "activate",
+		Description: "activate script is up to date",
+		Env:         checkActivate,
+	},
+	{
+		Name:        "goroot",
+		Description: "GOROOT of enviroment exists",
+		Env:         checkGoRoot,
+	},
+	{
+		Name:        "dirs",
+		Description: "bin and src dirs exists",
+		Env:         checkDirs,
+	},
+	{
+		Name:        "settings",
+		Description: "settings files are valid",
+		Env:         checkSettings,
+	},
+	{
+		Name:        "stray",
+		Description: "database has only enviroments",
+		DB:          checkStray,
+	},
+	{
+		Name:        "tmp",
+		Description: "temporary dir hasn't leftover items",
+		DB:          checkTmp,
+	},
+}
+
+// Doctor runs the DoctorChecks on enviroments names. If names is empty, checks
+// all enviroments and the database.
+func (env *GoEnv) Doctor(names ...string) (findings []*Finding, err error) {
+	all := len(names) == 0
+	if all {
+		if names, err = env.Ls(); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range names {
+		if _, err = env.GetCheck(name); err != nil {
+			return nil, err
+		}
+	}
+
+	add := func(check *DoctorCheck, envName string, f []*Finding, err error) error {
+		if err != nil {
+			if envName != "" {
+				return fmt.Errorf("Check %q of %q: %w", check.Name, envName, err)
+			}
+			return fmt.Errorf("Check %q: %w", check.Name, err)
+		}
+		for _, f := range f {
+			f.Check, f.Env = check.Name, envName
+		}
+		findings = append(findings, f...)
+		return nil
+	}
+
+	for _, check := range DoctorChecks {
+		if check.Env != nil {
+			for _, name := range names {
+				f, err := check.Env(env, name)
+				if err = add(check, name, f, err); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if check.DB != nil && all {
+			f, err := check.DB(env)
+			if err = add(check, "", f, err); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return
+}
+
+func checkActivate(env *GoEnv, name string) ([]*Finding, error) {
+	pth := filepath.Join(env.DbDir, name)
+	b, err := readGoVersionBinding(pth)
+	if err != nil {
+		return []*Finding{{
+			Severity: SeverityError,
+			Message:  err.Error(),
+		}}, nil
+	}
+	data, err := ioutil.ReadFile(filepath.Join(pth, "activate"))
+	if err != nil {
+		return nil, err
+	}
+	script, err := env.activateScript(pth, b.activateRoot())
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == script {
+		return nil, nil
+	}
+	return []*Finding{{
+		Severity: SeverityWarning,
+		Message:  "activate script is outdated",
+		fix: func() error {
+			return env.CreateActivate(pth, b.activateRoot())
+		},
+	}}, nil
+}
+
+func checkGoRoot(env *GoEnv, name string) ([]*Finding, error) {
+	info, err := env.EnvInfo(name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Broken {
+		return nil, nil
+	}
+	msg := fmt.Sprintf("GOROOT of version %q doesn't exists", info.GoVersion)
+	if info.GoRoot != "" {
+		msg = fmt.Sprintf("GOROOT %q of version %q doesn't exists", info.GoRoot, info.GoVersion)
+	}
+	return []*Finding{{Severity: SeverityError, Message: msg}}, nil
+}
+
+func checkDirs(env *GoEnv, name string) (findings []*Finding, err error) {
+	for _, dir := range []string{"bin", "src"} {
+		pth := filepath.Join(env.DbDir, name, dir)
+		ok, err := IsDir(pth)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			findings = append(findings, &Finding{
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s dir is missing", dir),
+				fix: func() error {
+					return MkdirAll(pth)
+				},
+			})
+		}
+	}
+	return
+}
+
+func checkSettings(env *GoEnv, name string) (findings []*Finding, err error) {
+	dir := filepath.Join(env.DbDir, name, SETTINGS_DIR)
+
+	pth := filepath.Join(dir, "backup_exclude")
+	if ok, err := IsFile(pth); err != nil {
+		return nil, err
+	} else if ok {
+		var patterns Patterns
+		lines, err := readLines(pth)
+		if err == nil {
+			err = patterns.Append(lines...)
+		}
+		if err != nil {
+			findings = append(findings, &Finding{Severity: SeverityError, Message: err.Error()})
+		}
+	}
+
+	if _, err := env.Tools(name); err != nil {
+		findings = append(findings, &Finding{Severity: SeverityError, Message: err.Error()})
+	}
+
+	pth = filepath.Join(dir, GOVERSION_BINDING_NAME)
+	if data, err := ioutil.ReadFile(pth); err == nil {
+		if err = json.Unmarshal(data, &GoVersionBinding{}); err != nil {
+			findings = append(findings, &Finding{
+				Severity: SeverityError,
+				Message:  errwrap.Wrap(err, "Decode %q", pth).Error(),
+			})
+		}
+	} else if !os.IsNotExist(err) {
+		findings = append(findings, &Finding{Severity: SeverityError, Message: err.Error()})
+	}
+	return
+}
+
+func checkStray(env *GoEnv) (findings []*Finding, err error) {
+	items, err := ioutil.ReadDir(env.DbDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range items {
+		if f.Name()[0] == '.' || (f.Name() == CONFIG_NAME && !f.IsDir()) {
+			continue
+		}
+		if f.IsDir() {
+			if ok, err := IsFile(env.DbDir, f.Name(), "activate"); err != nil || ok {
+				continue
+			}
+			findings = append(findings, &Finding{
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("dir %q isn't enviroment (activate script is missing)", f.Name()),
+			})
+		} else {
+			findings = append(findings, &Finding{
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("stray file %q", f.Name()),
+			})
+		}
+	}
+	return
+}
+
+// TmpMaxAge is the age of temporary items reported by the "tmp" check.
+// The newer items may be in use by running operations.
+var TmpMaxAge = 24 * time.Hour
+
+// staleTmpItems returns the items of temporary dir older than TmpMaxAge.
+func staleTmpItems(pth string) (items []os.FileInfo, err error) {
+	all, err := ioutil.ReadDir(pth)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for _, f := range all {
+		if time.Since(f.ModTime()) > TmpMaxAge {
+			items = append(items, f)
+		}
+	}
+	return
+}
+
+func checkTmp(env *GoEnv) ([]*Finding, error) {
+	pth := filepath.Join(env.DbDir, ".tmp")
+	items, err := staleTmpItems(pth)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return []*Finding{{
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("%d leftover items older than %s in %q", len(items), TmpMaxAge, pth),
+		fix: func() error {
+			// waits the operations using the temporary dir
+			_, unlock, err := env.LockDB(false)
+			if err != nil {
+				return err
+			}
+			defer unlock()
+			if items, err = staleTmpItems(pth); err != nil {
+				return err
+			}
+			for _, f := range items {
+				if err := os.RemoveAll(filepath.Join(pth, f.Name())); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}}, nil
+}
@@ -0,0 +1,74 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDoctorTmp(t *testing.T) {
+	env := newTestEnv(t, "e1")
+	tmp := filepath.Join(env.DbDir, ".tmp")
+	old, fresh := filepath.Join(tmp, "old"), filepath.Join(tmp, "fresh")
+	writeTestFile(t, filepath.Join(old, "data"), "x")
+	writeTestFile(t, fresh, "x")
+	past := time.Now().Add(-2 * TmpMaxAge)
+	if err := os.Chtimes(old, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := env.Doctor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tmpFinding *Finding
+	for _, f := range findings {
+		if f.Check == "tmp" {
+			tmpFinding = f
+		}
+	}
+	if tmpFinding == nil {
+		t.Fatalf("tmp finding not reported: %v", findings)
+	}
+
+	// the fix waits the operations of other processes
+	other, err := NewGoEnv(env.DbDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, unlock, err := other.LockDB(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.LockTimeout = 0
+	if err = tmpFinding.Fix(); !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("Fix while locked: %v, want ErrLockTimeout", err)
+	}
+	unlock()
+
+	if err = tmpFinding.Fix(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("old item isn't removed: %v", err)
+	}
+	if _, err = os.Stat(fresh); err != nil {
+		t.Errorf("fresh item is removed: %v", err)
+	}
+}
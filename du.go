@@ -0,0 +1,264 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/moisespsena-go/error-wrap"
+)
+
+// DU_OTHER is the DiskUsage area of entries not in known areas.
+const DU_OTHER = "other"
+
+// DiskUsage is the disk usage of enviroment or database dir, in bytes.
+type DiskUsage struct {
+	Name string `json:"name"`
+	// Areas maps the top level areas ("src", "pkg/mod", "pkg/<os_arch>",
+	// "bin" and "other") to their sizes. It's empty for database dirs.
+	Areas map[string]int64 `json:"areas,omitempty"`
+	Total int64            `json:"total"`
+}
+
+// DiskUsageDirs are the database dirs reported by DBDiskUsage.
+var DiskUsageDirs = []string{".backup", ".trash", VERSIONS_BASENAME, ".tmp", CACHE_BASENAME}
+
+// DiskUsageWorkers is the number of dirs read concurrently.
+var DiskUsageWorkers = runtime.NumCPU() * 4
+
+// duDir is a dir queued to be read by duWalker.
+type duDir struct {
+	pth   string
+	total *int64
+}
+
+// duWalker sums the sizes of trees with DiskUsageWorkers goroutines, which
+// read the queued dirs.
+type duWalker struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	// queue is read as stack, so the pending dirs are the siblings of current
+	// path, not the whole tree level.
+	queue []duDir
+	// reading is the count of dirs being read.
+	reading int
+	closed  bool
+	wg      sync.WaitGroup
+	errs    []error
+}
+
+func newDuWalker() *duWalker {
+	w := &duWalker{}
+	w.cond = sync.NewCond(&w.mu)
+	workers := DiskUsageWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	w.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go w.work()
+	}
+	return w
+}
+
+// add adds the size of tree pth to total. The size is computed
+// asynchronously, see wait.
+func (w *duWalker) add(pth string, info os.FileInfo, total *int64) {
+	if !info.IsDir() {
+		atomic.AddInt64(total, info.Size())
+		return
+	}
+	w.mu.Lock()
+	w.queue = append(w.queue, duDir{pth, total})
+	w.mu.Unlock()
+	w.cond.Signal()
+}
+
+func (w *duWalker) work() {
+	defer w.wg.Done()
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && !(w.closed && w.reading == 0) {
+			w.cond.Wait()
+		}
+		if len(w.queue) == 0 {
+			w.mu.Unlock()
+			// wakes the other workers to exit
+			w.cond.Broadcast()
+			return
+		}
+		d := w.queue[len(w.queue)-1]
+		w.queue = w.queue[:len(w.queue)-1]
+		w.reading++
+		w.mu.Unlock()
+
+		w.dir(d.pth, d.total)
+
+		w.mu.Lock()
+		w.reading--
+		done := w.closed && w.reading == 0 && len(w.queue) == 0
+		w.mu.Unlock()
+		if done {
+			w.cond.Broadcast()
+		}
+	}
+}
+
+func (w *duWalker) dir(pth string, total *int64) {
+	items, err := ioutil.ReadDir(pth)
+	if err != nil {
+		w.mu.Lock()
+		w.errs = append(w.errs, errwrap.Wrap(err, "Read dir %q", pth))
+		w.mu.Unlock()
+		return
+	}
+	for _, info := range items {
+		w.add(filepath.Join(pth, info.Name()), info, total)
+	}
+}
+
+// wait waits the sizes computation, stops the workers and returns the first
+// error. The walker can't be used after wait.
+func (w *duWalker) wait() error {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	w.cond.Broadcast()
+	w.wg.Wait()
+	if len(w.errs) > 0 {
+		return w.errs[0]
+	}
+	return nil
+}
+
+// envAreas adds the top level areas of enviroment dir pth to usage.
+func (w *duWalker) envAreas(pth string, areas map[string]*int64) error {
+	area := func(name string) *int64 {
+		if areas[name] == nil {
+			areas[name] = new(int64)
+		}
+		return areas[name]
+	}
+	items, err := ioutil.ReadDir(pth)
+	if err != nil {
+		return errwrap.Wrap(err, "Read dir %q", pth)
+	}
+	for _, info := range items {
+		p := filepath.Join(pth, info.Name())
+		switch {
+		case info.Name() == "src" || info.Name() == "bin":
+			w.add(p, info, area(info.Name()))
+		case info.Name() == "pkg" && info.IsDir():
+			pkgItems, err := ioutil.ReadDir(p)
+			if err != nil {
+				return errwrap.Wrap(err, "Read dir %q", p)
+			}
+			for _, pkgInfo := range pkgItems {
+				name := DU_OTHER
+				if pkgInfo.IsDir() {
+					name = "pkg/" + pkgInfo.Name()
+				}
+				w.add(filepath.Join(p, pkgInfo.Name()), pkgInfo, area(name))
+			}
+		default:
+			w.add(p, info, area(DU_OTHER))
+		}
+	}
+	return nil
+}
+
+// DiskUsage returns the disk usage of enviroments. If names is empty, all
+// enviroments are reported.
+func (env *GoEnv) DiskUsage(names ...string) (usage []*DiskUsage, err error) {
+	if len(names) == 0 {
+		if names, err = env.Ls(); err != nil {
+			return nil, err
+		}
+	}
+	env, unlock, err := env.LockEnv(true, names...)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	w := newDuWalker()
+	areas := make([]map[string]*int64, len(names))
+	for i, name := range names {
+		pth, err := env.GetCheck(name)
+		if err != nil {
+			return nil, err
+		}
+		areas[i] = map[string]*int64{}
+		if err = w.envAreas(pth, areas[i]); err != nil {
+			w.wait()
+			return nil, err
+		}
+	}
+	if err = w.wait(); err != nil {
+		return nil, err
+	}
+	for i, name := range names {
+		u := &DiskUsage{Name: name, Areas: map[string]int64{}}
+		for area, size := range areas[i] {
+			u.Areas[area] = *size
+			u.Total += *size
+		}
+		usage = append(usage, u)
+	}
+	return
+}
+
+// DBDiskUsage returns the disk usage of database dirs (see DiskUsageDirs).
+// The missing dirs are reported with zero size.
+func (env *GoEnv) DBDiskUsage() (usage []*DiskUsage, err error) {
+	w := newDuWalker()
+	totals := make([]int64, len(DiskUsageDirs))
+	for i, name := range DiskUsageDirs {
+		pth := filepath.Join(env.DbDir, name)
+		info, err := os.Lstat(pth)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			w.wait()
+			return nil, err
+		}
+		w.add(pth, info, &totals[i])
+	}
+	if err = w.wait(); err != nil {
+		return nil, err
+	}
+	for i, name := range DiskUsageDirs {
+		usage = append(usage, &DiskUsage{Name: name, Total: totals[i]})
+	}
+	return
+}
+
+// SortDiskUsage sorts the usage by name, or by total size (largest first) if
+// bySize is true.
+func SortDiskUsage(usage []*DiskUsage, bySize bool) {
+	sort.SliceStable(usage, func(i, j int) bool {
+		if bySize && usage[i].Total != usage[j].Total {
+			return usage[i].Total > usage[j].Total
+		}
+		return usage[i].Name < usage[j].Name
+	})
+}
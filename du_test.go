@@ -0,0 +1,131 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestTree writes n files of size bytes into nested dirs of dir, and
+// returns the written size.
+func writeTestTree(t *testing.T, dir string, depth, n, size int) (total int64) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		sub := dir
+		for d := 0; d < i%(depth+1); d++ {
+			sub = filepath.Join(sub, fmt.Sprintf("d%d", d))
+		}
+		writeTestFile(t, filepath.Join(sub, fmt.Sprintf("f%d", i)), strings.Repeat("x", size))
+		total += int64(size)
+	}
+	return
+}
+
+func TestDiskUsage(t *testing.T) {
+	env := newTestEnv(t, "e1", "e2")
+	pth := filepath.Join(env.DbDir, "e1")
+	osArch := "pkg/" + runtime.GOOS + "_" + runtime.GOARCH
+	want := map[string]int64{
+		"src":     writeTestTree(t, filepath.Join(pth, "src", "example.com"), 5, 40, 100) + int64(len("package main // e1\n")),
+		"bin":     writeTestTree(t, filepath.Join(pth, "bin"), 0, 3, 1000),
+		"pkg/mod": writeTestTree(t, filepath.Join(pth, "pkg", "mod"), 8, 300, 10),
+		osArch:    writeTestTree(t, filepath.Join(pth, filepath.FromSlash(osArch)), 2, 10, 7),
+	}
+	writeTestFile(t, filepath.Join(pth, "pkg", "README"), "pkg")
+	other, _, err := scanSize(pth, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	for _, size := range want {
+		other -= size
+		total += size
+	}
+	want[DU_OTHER] = other
+	total += other
+
+	goroutines := runtime.NumGoroutine()
+	workers := DiskUsageWorkers
+	defer func() { DiskUsageWorkers = workers }()
+	for _, DiskUsageWorkers = range []int{1, 2, workers} {
+		usage, err := env.DiskUsage("e1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(usage) != 1 {
+			t.Fatalf("%d usages, want 1", len(usage))
+		}
+		u := usage[0]
+		if u.Name != "e1" || u.Total != total || len(u.Areas) != len(want) {
+			t.Errorf("workers %d: usage %+v, want total %d, areas %v", DiskUsageWorkers, *u, total, want)
+		}
+		for area, size := range want {
+			if u.Areas[area] != size {
+				t.Errorf("workers %d: area %s is %d, want %d", DiskUsageWorkers, area, u.Areas[area], size)
+			}
+		}
+	}
+
+	// the workers exit
+	for i := 0; runtime.NumGoroutine() > goroutines; i++ {
+		if i == 100 {
+			t.Fatalf("%d goroutines are running, want %d", runtime.NumGoroutine(), goroutines)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	usage, err := env.DiskUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usage) != 2 || usage[0].Name != "e1" || usage[0].Total != total || usage[1].Name != "e2" {
+		t.Errorf("usage of all enviroments %+v", usage)
+	}
+}
+
+func TestDBDiskUsage(t *testing.T) {
+	env := newTestEnv(t)
+	trash := writeTestTree(t, filepath.Join(env.DbDir, ".trash"), 4, 50, 20)
+	cache := writeTestTree(t, filepath.Join(env.DbDir, CACHE_BASENAME), 10, 200, 3)
+
+	usage, err := env.DBDiskUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usage) != len(DiskUsageDirs) {
+		t.Fatalf("%d usages, want %d", len(usage), len(DiskUsageDirs))
+	}
+	for _, u := range usage {
+		var want int64
+		switch u.Name {
+		case ".trash":
+			want = trash
+		case CACHE_BASENAME:
+			want = cache
+		case ".backup", VERSIONS_BASENAME:
+			// missing dirs
+		default:
+			continue
+		}
+		if u.Total != want {
+			t.Errorf("%s: total %d, want %d", u.Name, u.Total, want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/moisespsena-go/error-wrap"
+)
+
+// ENCRYPTED_EXT is the file extension of encrypted backups.
+const ENCRYPTED_EXT = ".age"
+
+const (
+	ageHeader      = "age-encryption.org/v1"
+	ageArmorHeader = armor.Header
+)
+
+// IdentitiesFunc returns the identities used to decrypt a backup. It is only
+// called when the backup is encrypted.
+type IdentitiesFunc func() ([]age.Identity, error)
+
+// ParseRecipients parses age X25519 recipients (age1...).
+func ParseRecipients(values ...string) (recipients []age.Recipient, err error) {
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		r, err := age.ParseX25519Recipient(value)
+		if err != nil {
+			return nil, errwrap.Wrap(err, "Parse recipient %q", value)
+		}
+		recipients = append(recipients, r)
+	}
+	return
+}
+
+// ReadRecipientsFile reads age recipients from file, one per line.
+func ReadRecipientsFile(pth string) ([]age.Recipient, error) {
+	f, err := os.Open(pth)
+	if err != nil {
+		return nil, errwrap.Wrap(err, "Open recipients file %q", pth)
+	}
+	defer f.Close()
+	recipients, err := age.ParseRecipients(f)
+	if err != nil {
+		return nil, errwrap.Wrap(err, "Parse recipients file %q", pth)
+	}
+	return recipients, nil
+}
+
+// ReadIdentitiesFile reads age identities (AGE-SECRET-KEY-...) from file.
+func ReadIdentitiesFile(pth string) ([]age.Identity, error) {
+	f, err := os.Open(pth)
+	if err != nil {
+		return nil, errwrap.Wrap(err, "Open identity file %q", pth)
+	}
+	defer f.Close()
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, errwrap.Wrap(err, "Parse identity file %q", pth)
+	}
+	return identities, nil
+}
+
+// PassphraseRecipient returns the recipient for encrypt with passphrase.
+func PassphraseRecipient(passphrase string) (age.Recipient, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("Empty passphrase.")
+	}
+	return age.NewScryptRecipient(passphrase)
+}
+
+// PassphraseIdentity returns the identity for decrypt with passphrase.
+func PassphraseIdentity(passphrase string) (age.Identity, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("Empty passphrase.")
+	}
+	return age.NewScryptIdentity(passphrase)
+}
+
+func encryptWriter(writer io.Writer, recipients []age.Recipient) (io.WriteCloser, error) {
+	w, err := age.Encrypt(writer, recipients...)
+	if err != nil {
+		return nil, errwrap.Wrap(err, "Encrypt")
+	}
+	return w, nil
+}
+
+// IsEncrypted reports whether the reader starts with an age header. The
+// returned reader must be used in place of reader.
+func IsEncrypted(reader io.Reader) (io.Reader, bool, error) {
+	br := bufio.NewReader(reader)
+	for _, header := range []string{ageHeader, ageArmorHeader} {
+		data, err := br.Peek(len(header))
+		if err != nil {
+			if err == io.EOF {
+				continue
+			}
+			return br, false, err
+		}
+		if bytes.Equal(data, []byte(header)) {
+			return br, true, nil
+		}
+	}
+	return br, false, nil
+}
+
+func decryptReader(reader io.Reader, identities IdentitiesFunc) (io.Reader, error) {
+	reader, encrypted, err := IsEncrypted(reader)
+	if err != nil {
+		return nil, errwrap.Wrap(err, "Read header")
+	}
+	if !encrypted {
+		return reader, nil
+	}
+	if identities == nil {
+		return nil, fmt.Errorf("The backup is encrypted and no identity was defined.")
+	}
+	ids, err := identities()
+	if err != nil {
+		return nil, errwrap.Wrap(err, "Get identities")
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("The backup is encrypted and no identity was defined.")
+	}
+	if data, _ := reader.(*bufio.Reader).Peek(len(ageArmorHeader)); string(data) == ageArmorHeader {
+		reader = armor.NewReader(reader)
+	}
+	r, err := age.Decrypt(reader, ids...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, fmt.Errorf("The identities or passphrase don't decrypt the backup: %w", err)
+		}
+		return nil, errwrap.Wrap(err, "Decrypt")
+	}
+	return r, nil
+}
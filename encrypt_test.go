@@ -0,0 +1,160 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+)
+
+// backupEncrypted backups the enviroment "e1" of env encrypted to
+// recipients, and checks the backup is detected as encrypted.
+func backupEncrypted(t *testing.T, env *GoEnv, recipients ...age.Recipient) string {
+	t.Helper()
+	target := filepath.Join(t.TempDir(), "e1.tar.gz")
+	if _, err := env.Backup("e1", &BackupOptions{Target: target, Recipients: recipients}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, encrypted, err := IsEncrypted(f); err != nil || !encrypted {
+		t.Fatalf("backup isn't encrypted (err %v)", err)
+	}
+	return target
+}
+
+func restoreEncrypted(env *GoEnv, source string, identities ...age.Identity) error {
+	_, err := env.Restore(&RestoreOptions{Source: source, Name: "e2", Identities: func() ([]age.Identity, error) {
+		return identities, nil
+	}})
+	return err
+}
+
+func checkRestored(t *testing.T, env *GoEnv) {
+	t.Helper()
+	if data := readTestFile(t, filepath.Join(env.DbDir, "e2", "src", "e1", "main.go")); data != "package main // e1\n" {
+		t.Errorf("restored file is %q", data)
+	}
+}
+
+func checkNotRestored(t *testing.T, env *GoEnv) {
+	t.Helper()
+	if _, err := os.Stat(filepath.Join(env.DbDir, "e2")); !os.IsNotExist(err) {
+		t.Errorf("enviroment is restored: %v", err)
+	}
+}
+
+func TestBackupEncryptedX25519(t *testing.T) {
+	env := newTestEnv(t, "e1")
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipients, err := ParseRecipients(identity.Recipient().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := backupEncrypted(t, env, recipients...)
+
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = restoreEncrypted(env, source, other)
+	if err == nil || !strings.Contains(err.Error(), "don't decrypt the backup") {
+		t.Errorf("restore with wrong identity: %v", err)
+	}
+	checkNotRestored(t, env)
+
+	err = restoreEncrypted(env, source)
+	if err == nil || !strings.Contains(err.Error(), "no identity was defined") {
+		t.Errorf("restore without identity: %v", err)
+	}
+	checkNotRestored(t, env)
+
+	if err = restoreEncrypted(env, source, identity); err != nil {
+		t.Fatal(err)
+	}
+	checkRestored(t, env)
+}
+
+func TestBackupEncryptedPassphrase(t *testing.T) {
+	env := newTestEnv(t, "e1")
+	recipient, err := PassphraseRecipient("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the default work factor takes about one second
+	recipient.(*age.ScryptRecipient).SetWorkFactor(10)
+	source := backupEncrypted(t, env, recipient)
+
+	wrong, err := PassphraseIdentity("wrong")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = restoreEncrypted(env, source, wrong)
+	if err == nil || !strings.Contains(err.Error(), "don't decrypt the backup") {
+		t.Errorf("restore with wrong passphrase: %v", err)
+	}
+	checkNotRestored(t, env)
+
+	identity, err := PassphraseIdentity("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = restoreEncrypted(env, source, identity); err != nil {
+		t.Fatal(err)
+	}
+	checkRestored(t, env)
+}
+
+func TestPassphraseEmpty(t *testing.T) {
+	if _, err := PassphraseRecipient(""); err == nil {
+		t.Error("recipient of empty passphrase")
+	}
+	if _, err := PassphraseIdentity(""); err == nil {
+		t.Error("identity of empty passphrase")
+	}
+}
+
+func TestIsEncrypted(t *testing.T) {
+	for data, want := range map[string]bool{
+		ageHeader + "\n-> X25519 abc\n": true,
+		ageArmorHeader + "\nYWdl\n":     true,
+		"\x1f\x8b\x08\x00":              false,
+		"age":                           false,
+		"":                              false,
+	} {
+		r, encrypted, err := IsEncrypted(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("%q: %v", data, err)
+		}
+		if encrypted != want {
+			t.Errorf("%q: encrypted %v, want %v", data, encrypted, want)
+		}
+		// the header is kept in returned reader
+		if rest, _ := ioutil.ReadAll(r); string(rest) != data {
+			t.Errorf("%q: reader returns %q", data, rest)
+		}
+	}
+}
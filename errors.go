@@ -0,0 +1,112 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The errors of goenv package. They are wrapped by DBError, EnvError,
+// VersionError or LockTimeoutError, so check them with errors.Is.
+var (
+	// ErrDBNotInitialized is the database directory doesn't exists.
+	ErrDBNotInitialized = errors.New("database isn't initialized")
+	// ErrEnvNotFound is the enviroment directory doesn't exists.
+	ErrEnvNotFound = errors.New("enviroment not found")
+	// ErrNotEnv is the directory exists, but hasn't the activate script.
+	ErrNotEnv = errors.New("isn't GoLang enviroment")
+	// ErrEnvExists is the enviroment exists and can't be replaced.
+	ErrEnvExists = errors.New("enviroment exists")
+	// ErrVersionNotInstalled is the Go version isn't installed.
+	ErrVersionNotInstalled = errors.New("GoLang version isn't installed")
+	// ErrVersionNotAvailable is the Go version isn't available for download.
+	ErrVersionNotAvailable = errors.New("GoLang version isn't available")
+	// ErrVersionInUse is the Go version is used by enviroments.
+	ErrVersionInUse = errors.New("GoLang version is in use")
+	// ErrDBFormat is the database format is newer than supported.
+	ErrDBFormat = errors.New("database format isn't supported")
+	// ErrDBOutdated is the database format is older than DB_FORMAT.
+	ErrDBOutdated = errors.New("database format is outdated")
+	// ErrLockTimeout is the lock isn't acquired before timeout.
+	ErrLockTimeout = errors.New("lock timeout")
+)
+
+// DBError is the error of database Dir.
+type DBError struct {
+	Dir string
+	Err error
+}
+
+func (e *DBError) Error() string {
+	switch e.Err {
+	case ErrDBNotInitialized:
+		return fmt.Sprintf("Database %q isn't initialized. Run 'goenv db init'.", e.Dir)
+	case ErrDBFormat:
+		return fmt.Sprintf("Database %q format is newer than supported (%d). Upgrade goenv.", e.Dir, DB_FORMAT)
+	case ErrDBOutdated:
+		return fmt.Sprintf("Database %q format is outdated. Run 'goenv db migrate'.", e.Dir)
+	}
+	return fmt.Sprintf("Database %q: %v", e.Dir, e.Err)
+}
+
+func (e *DBError) Unwrap() error {
+	return e.Err
+}
+
+// EnvError is the error of enviroment Name on Path.
+type EnvError struct {
+	Name string
+	Path string
+	Err  error
+}
+
+func (e *EnvError) Error() string {
+	switch e.Err {
+	case ErrEnvNotFound:
+		return fmt.Sprintf("Enviroment %q not found on %q.", e.Name, e.Path)
+	case ErrNotEnv:
+		return fmt.Sprintf("%v is not GoLang enviroment.", e.Path)
+	case ErrEnvExists:
+		return fmt.Sprintf("Enviroment %q on %q exists.", e.Name, e.Path)
+	}
+	return fmt.Sprintf("%v: %v", e.Path, e.Err)
+}
+
+func (e *EnvError) Unwrap() error {
+	return e.Err
+}
+
+// VersionError is the error of Go Version.
+type VersionError struct {
+	Version string
+	Err     error
+}
+
+func (e *VersionError) Error() string {
+	switch e.Err {
+	case ErrVersionNotInstalled:
+		return fmt.Sprintf("GoLang version %q has not be installed.", e.Version)
+	case ErrVersionNotAvailable:
+		return fmt.Sprintf("GoLang version %q isn't available.", e.Version)
+	case ErrVersionInUse:
+		return fmt.Sprintf("GoLang version %q is in use.", e.Version)
+	}
+	return fmt.Sprintf("GoLang version %q: %v", e.Version, e.Err)
+}
+
+func (e *VersionError) Unwrap() error {
+	return e.Err
+}
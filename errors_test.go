@@ -0,0 +1,134 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	env := newTestEnv(t, "e1")
+	other, err := NewGoEnv(env.DbDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, unlock, err := other.LockEnv(false, "e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.LockTimeout = 0
+	_, _, lockErr := env.LockEnv(false, "e1")
+	unlock()
+
+	// the errors of nested operations keep the cause
+	vs := NewGoVersions(env)
+	if err = MkdirAll(vs.Dir()); err != nil {
+		t.Fatal(err)
+	}
+	newTestInstalled(t, vs, "go1.99.1")
+	changes, err := env.PlanSpec(&Spec{Environments: []*EnvSpec{{Name: "e4", Go: "1.99.1"}}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, unlock, err = NewGoVersions(other).Lock(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	initErr := env.InitWith("e3", &InitOptions{GoVersion: "go1.99.1"})
+	applyErr := env.ApplySpec(changes, ioutil.Discard, ioutil.Discard)
+	unlock()
+
+	writeTestFile(t, filepath.Join(env.DbDir, "notenv", "file"), "")
+	source := filepath.Join(t.TempDir(), "e1.tar.gz")
+	if _, err = env.Backup("e1", &BackupOptions{Target: source}); err != nil {
+		t.Fatal(err)
+	}
+	cmd := &GoEnvCmd{Env: env}
+
+	newerDB := filepath.Join(t.TempDir(), "newer")
+	writeTestFile(t, filepath.Join(newerDB, DB_FORMAT_NAME), strconv.Itoa(DB_FORMAT+1))
+	olderDB := filepath.Join(t.TempDir(), "older")
+	writeTestFile(t, filepath.Join(olderDB, "e1", "activate"), "")
+
+	call := func(f func() error) error { return f() }
+	for _, c := range []struct {
+		name   string
+		err    error
+		target error
+		as     interface{}
+	}{
+		{"NewGoEnv", call(func() error {
+			_, err := NewGoEnv(filepath.Join(t.TempDir(), "missing"), true)
+			return err
+		}), ErrDBNotInitialized, new(*DBError)},
+		{"GetCheck missing", call(func() error {
+			_, err := env.GetCheck("missing")
+			return err
+		}), ErrEnvNotFound, new(*EnvError)},
+		{"GetCheck not env", call(func() error {
+			_, err := env.GetCheck("notenv")
+			return err
+		}), ErrNotEnv, new(*EnvError)},
+		{"Backup missing", cmd.Backup("missing", &BackupOptions{Target: filepath.Join(t.TempDir(), "x.tar.gz")}),
+			ErrEnvNotFound, new(*EnvError)},
+		{"Restore exists", cmd.Restore(&RestoreOptions{Source: source}), ErrEnvExists, new(*EnvError)},
+		{"Set not installed", vs.Set("go1.99", "e1"), ErrVersionNotInstalled, new(*VersionError)},
+		{"Set missing env", vs.Set("go1.99", "missing"), ErrEnvNotFound, new(*EnvError)},
+		{"Rm not installed", vs.Rm("go1.99", false), ErrVersionNotInstalled, new(*VersionError)},
+		{"Doctor missing", call(func() error {
+			_, err := env.Doctor("missing")
+			return err
+		}), ErrEnvNotFound, new(*EnvError)},
+		{"LockEnv", lockErr, ErrLockTimeout, new(*LockTimeoutError)},
+		{"InitWith", initErr, ErrLockTimeout, new(*LockTimeoutError)},
+		{"ApplySpec", applyErr, ErrLockTimeout, new(*LockTimeoutError)},
+		{"CheckDBFormat", call(func() error {
+			_, err := CheckDBFormat(newerDB)
+			return err
+		}), ErrDBFormat, new(*DBError)},
+		{"InitDB", call(func() error {
+			env, err := NewGoEnv(olderDB, true)
+			if err != nil {
+				return err
+			}
+			_, err = env.InitDB()
+			return err
+		}), ErrDBOutdated, new(*DBError)},
+	} {
+		if !errors.Is(c.err, c.target) {
+			t.Errorf("%s: errors.Is(%v, %v) is false", c.name, c.err, c.target)
+		}
+		if !errors.As(c.err, c.as) {
+			t.Errorf("%s: errors.As(%v, %T) is false", c.name, c.err, c.as)
+		}
+	}
+}
+
+func TestErrorsContext(t *testing.T) {
+	for _, err := range []error{
+		&DBError{"db", ErrDBNotInitialized},
+		&EnvError{"e1", "db/e1", ErrEnvNotFound},
+		&VersionError{"go1.99", ErrVersionNotAvailable},
+		&VersionError{"go1.99", ErrVersionInUse},
+	} {
+		if target := errors.Unwrap(err); err.Error() == target.Error() {
+			t.Errorf("%v hasn't the context", err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import "time"
+
+// EventType is the type of Event.
+type EventType int
+
+const (
+	// EventAvailableFailed is sent when the available versions can't be
+	// fetched and only the mirrors are used. Err is the cause.
+	EventAvailableFailed EventType = iota
+	// EventDownloadStart is sent before the download of Version from URL.
+	EventDownloadStart
+	// EventDownloadResponse is sent on HTTP response. Total is the size left,
+	// or -1 if the status isn't 200 or 206.
+	EventDownloadResponse
+	// EventDownloadProgress is sent periodically while downloading.
+	EventDownloadProgress
+	// EventDownloadDone is sent when the download is saved to Path.
+	EventDownloadDone
+	// EventDownloadURLFailed is sent when the download from URL fails and
+	// the next URL is tried.
+	EventDownloadURLFailed
+	// EventDownloadRetry is sent before the retry Attempt of Retries, after
+	// Backoff.
+	EventDownloadRetry
+	// EventDownloadFailed is sent when all URLs and retries fails. The
+	// version is skipped.
+	EventDownloadFailed
+	// EventExtractStart is sent before extract the archive Path of Version to
+	// Target.
+	EventExtractStart
+	// EventExtractDone is sent after the extraction. Err is the failure.
+	EventExtractDone
+	// EventExtractEntry is sent for each archive entry extracted in verbose
+	// mode. Kind is the tar entry type, Path the name (and link target),
+	// Bytes the file size (-1 if not regular file) and Err the failure.
+	EventExtractEntry
+)
+
+// Event is the notification of GoEnv and GoVersions operations. Only the
+// fields documented by Type are defined.
+type Event struct {
+	Type    EventType
+	Version string
+	URL     string
+	Path    string
+	Target  string
+	Kind    string
+	// Status is the HTTP status.
+	Status  string
+	Bytes   int64
+	Total   int64
+	Attempt int
+	Retries int
+	Backoff time.Duration
+	Err     error
+}
+
+// Logger receives the events of GoEnv and GoVersions.
+type Logger interface {
+	Event(e *Event)
+}
+
+// LoggerFunc is a Logger function.
+type LoggerFunc func(e *Event)
+
+func (f LoggerFunc) Event(e *Event) {
+	f(e)
+}
+
+// NopLogger discards the events.
+var NopLogger Logger = LoggerFunc(func(e *Event) {})
+
+// Option configures the GoEnv.
+type Option func(env *GoEnv)
+
+// WithLogger sets the Logger of GoEnv, also used by its GoVersions.
+func WithLogger(logger Logger) Option {
+	return func(env *GoEnv) {
+		env.Logger = logger
+	}
+}
+
+// WithLockTimeout overrides the lock.timeout config.
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(env *GoEnv) {
+		env.LockTimeout = timeout
+	}
+}
+
+// VersionsOption configures the GoVersions.
+type VersionsOption func(vs *GoVersions)
+
+// WithVersionsLogger sets the Logger of GoVersions. Defaults to the Logger of
+// GoEnv.
+func WithVersionsLogger(logger Logger) VersionsOption {
+	return func(vs *GoVersions) {
+		vs.Logger = logger
+	}
+}
+
+func (env *GoEnv) event(e *Event) {
+	if env.Logger != nil {
+		env.Logger.Event(e)
+	}
+}
+
+func (v *GoVersions) event(e *Event) {
+	if v.Logger != nil {
+		v.Logger.Event(e)
+	} else {
+		v.Env.event(e)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/moisespsena-go/error-wrap"
+)
+
+// KEEP_NAME is the file, in versions directory, of versions names kept by GC.
+const KEEP_NAME = ".keep"
+
+func (vs *GoVersions) keepPath() string {
+	return filepath.Join(vs.Dir(), KEEP_NAME)
+}
+
+// Kept returns the names of versions marked as kept.
+func (vs *GoVersions) Kept() (names []string, err error) {
+	ok, err := IsFile(vs.keepPath())
+	if err != nil || !ok {
+		return nil, err
+	}
+	lines, err := readLines(vs.keepPath())
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			names = append(names, line)
+		}
+	}
+	return
+}
+
+// Keep marks (or unmarks if keep is false) the versions as kept, so GC
+// doesn't removes them.
+func (vs *GoVersions) Keep(keep bool, names ...string) error {
+	vs, unlock, err := vs.Lock(false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	kept, err := vs.Kept()
+	if err != nil {
+		return err
+	}
+	set := map[string]bool{}
+	for _, name := range kept {
+		set[name] = true
+	}
+	for _, name := range names {
+		set[strings.ToLower(name)] = keep
+	}
+	kept = kept[:0]
+	for name, ok := range set {
+		if ok {
+			kept = append(kept, name)
+		}
+	}
+	sort.Strings(kept)
+
+	if err = MkdirAll(vs.Dir()); err != nil {
+		return err
+	}
+	var data string
+	if len(kept) > 0 {
+		data = strings.Join(kept, "\n") + "\n"
+	}
+	if err = ioutil.WriteFile(vs.keepPath(), []byte(data), 0644); err != nil {
+		return errwrap.Wrap(err, "Write %q", vs.keepPath())
+	}
+	return nil
+}
+
+// Archives returns the paths of downloaded archives saved into versions
+// directory.
+func (vs *GoVersions) Archives() (archives []string, err error) {
+	dir, exists, err := vs.DirExists()
+	if err != nil || !exists {
+		return nil, err
+	}
+	items, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errwrap.Wrap(err, "Readdir %q", dir)
+	}
+	for _, f := range items {
+		if f.Mode().IsRegular() && (strings.HasSuffix(f.Name(), ".tar.gz") || strings.HasSuffix(f.Name(), ".zip")) {
+			archives = append(archives, filepath.Join(dir, f.Name()))
+		}
+	}
+	return
+}
+
+// archiveVersion returns the version name of archive file name, as "go1.21.3"
+// of "go1.21.3.linux-amd64.tar.gz", or empty if isn't a Go archive of this
+// system.
+func archiveVersion(pth string) string {
+	name := filepath.Base(pth)
+	if i := strings.Index(name, "."+runtime.GOOS+"-"); i > 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+type GCOptions struct {
+	// KeepArchives doesn't removes the downloaded archives.
+	KeepArchives bool
+	// DryRun only reports what would be removed.
+	DryRun bool
+}
+
+type GCResult struct {
+	// Versions are the names of removed versions.
+	Versions []string
+	// Archives are the paths of removed archives.
+	Archives []string
+	// Reclaimed is the size of removed files.
+	Reclaimed int64
+}
+
+// GC removes the installed versions not used by any enviroment and not
+// marked as kept, and the downloaded archives of installed versions. The
+// archives of other versions are partial downloads, resumed by the next
+// download. External toolchains (see Add) are never removed.
+func (vs *GoVersions) GC(options *GCOptions) (result *GCResult, err error) {
+	vs, unlock, err := vs.Lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	installed, err := vs.Ls()
+	if err != nil {
+		return nil, err
+	}
+	usage, err := vs.Usage()
+	if err != nil {
+		return nil, err
+	}
+	kept, err := vs.Kept()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range kept {
+		usage[name] = append(usage[name], "")
+	}
+
+	result = &GCResult{}
+	installedNames := map[string]bool{}
+	for _, v := range installed {
+		installedNames[v.Name] = true
+	}
+	for _, v := range installed {
+		if v.LinkTarget != "" || len(usage[v.Name]) > 0 {
+			continue
+		}
+		size, _, err := scanSize(v.Root, nil)
+		if err != nil {
+			return nil, errwrap.Wrap(err, "Size of %q", v.Root)
+		}
+		if !options.DryRun {
+			if err = os.RemoveAll(v.Root); err != nil {
+				return nil, errwrap.Wrap(err, "Remove %q", v.Root)
+			}
+		}
+		result.Versions = append(result.Versions, v.Name)
+		result.Reclaimed += size
+	}
+
+	if !options.KeepArchives {
+		archives, err := vs.Archives()
+		if err != nil {
+			return nil, err
+		}
+		for _, pth := range archives {
+			if !installedNames[archiveVersion(pth)] {
+				continue
+			}
+			info, err := os.Stat(pth)
+			if err != nil {
+				return nil, errwrap.Wrap(err, "Stat of %q", pth)
+			}
+			if !options.DryRun {
+				if err = os.Remove(pth); err != nil {
+					return nil, errwrap.Wrap(err, "Remove %q", pth)
+				}
+			}
+			result.Archives = append(result.Archives, pth)
+			result.Reclaimed += info.Size()
+		}
+	}
+	return
+}
@@ -0,0 +1,134 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+)
+
+// newTestInstalled installs the fake version name into vs.
+func newTestInstalled(t *testing.T, vs *GoVersions, name string) {
+	t.Helper()
+	if err := os.Rename(newTestGoRoot(t, name), filepath.Join(vs.Dir(), name)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testArchivePath(vs *GoVersions, name string) string {
+	return filepath.Join(vs.Dir(), name+"."+runtime.GOOS+"-"+runtime.GOARCH+".tar.gz")
+}
+
+func TestGC(t *testing.T) {
+	vs := NewGoVersions(newTestEnv(t, "e1"))
+	if err := os.MkdirAll(vs.Dir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"go1.96", "go1.97", "go1.98"} {
+		newTestInstalled(t, vs, name)
+	}
+	if _, err := vs.Add("go1.95-distro", newTestGoRoot(t, "go1.95")); err != nil {
+		t.Fatal(err)
+	}
+	if err := vs.Set("go1.97", "e1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := vs.Keep(true, "go1.96"); err != nil {
+		t.Fatal(err)
+	}
+	// go1.99 isn't installed: its archive is a partial download
+	for _, name := range []string{"go1.97", "go1.98", "go1.99"} {
+		writeTestFile(t, testArchivePath(vs, name), "archive")
+	}
+
+	want := &GCResult{
+		Versions:  []string{"go1.98"},
+		Archives:  []string{testArchivePath(vs, "go1.97"), testArchivePath(vs, "go1.98")},
+		Reclaimed: 2 * int64(len("archive")),
+	}
+	size, _, err := scanSize(filepath.Join(vs.Dir(), "go1.98"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Reclaimed += size
+
+	for _, dryRun := range []bool{true, false} {
+		result, err := vs.GC(&GCOptions{DryRun: dryRun})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(result.Archives)
+		if !reflect.DeepEqual(result, want) {
+			t.Errorf("dry run %v: result %+v, want %+v", dryRun, result, want)
+		}
+		_, err = os.Stat(filepath.Join(vs.Dir(), "go1.98"))
+		if removed := os.IsNotExist(err); removed == dryRun {
+			t.Errorf("dry run %v: version is removed %v", dryRun, removed)
+		}
+	}
+
+	var names []string
+	installed, err := vs.Ls()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range installed {
+		names = append(names, v.Name)
+	}
+	sort.Strings(names)
+	if want := []string{"go1.95-distro", "go1.96", "go1.97"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("installed %v, want %v", names, want)
+	}
+	if ok, _ := IsFile(testArchivePath(vs, "go1.99")); !ok {
+		t.Error("partial download is removed")
+	}
+}
+
+func TestKeep(t *testing.T) {
+	vs := NewGoVersions(newTestEnv(t))
+	if err := vs.Keep(true, "go1.21", "GO1.22", "go1.21"); err != nil {
+		t.Fatal(err)
+	}
+	if err := vs.Keep(true, "go1.20"); err != nil {
+		t.Fatal(err)
+	}
+	if err := vs.Keep(false, "go1.21", "go1.23"); err != nil {
+		t.Fatal(err)
+	}
+	kept, err := vs.Kept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"go1.20", "go1.22"}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("kept %v, want %v", kept, want)
+	}
+}
+
+func TestArchiveVersion(t *testing.T) {
+	for name, want := range map[string]string{
+		"go1.21.3." + runtime.GOOS + "-" + runtime.GOARCH + ".tar.gz": "go1.21.3",
+		"go1.22rc1." + runtime.GOOS + "-arm64.zip":                    "go1.22rc1",
+		"go1.21.3.plan10-amd64.tar.gz":                                "",
+		"notes.tar.gz":                                                "",
+	} {
+		if v := archiveVersion(filepath.Join("dir", name)); v != want {
+			t.Errorf("%s: version %q, want %q", name, v, want)
+		}
+	}
+}
@@ -1,17 +1,33 @@
 module github.com/moisespsena-go/goenv
 
-go 1.13
+go 1.19
 
 require (
+	filippo.io/age v1.2.1
+	github.com/BurntSushi/toml v1.3.2
 	github.com/PuerkitoBio/goquery v1.5.0
 	github.com/cavaliercoder/grab v2.0.0+incompatible
 	github.com/dustin/go-humanize v1.0.0
-	github.com/go-errors/errors v1.0.1 // indirect
 	github.com/gobwas/glob v0.2.3
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/moisespsena-go/error-wrap v0.0.0-20190401221633-16a254c7a0f6
 	github.com/moisespsena/go-ioutil v0.0.0-20190401220850-65da4827845a
 	github.com/phayes/permbits v0.0.0-20190612203442-39d7c581d2ee
+	github.com/pkg/sftp v1.13.6
 	github.com/spf13/cobra v0.0.5
+	golang.org/x/crypto v0.24.0
+	golang.org/x/mod v0.18.0
+	golang.org/x/sys v0.21.0
+	golang.org/x/term v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/andybalholm/cascadia v1.0.0 // indirect
+	github.com/go-errors/errors v1.0.1 // indirect
+	github.com/inconshreveable/mousetrap v1.0.0 // indirect
+	github.com/kr/fs v0.1.0 // indirect
+	github.com/spf13/pflag v1.0.3 // indirect
 	go4.org v0.0.0-20191010144846-132d2879e1e9 // indirect
+	golang.org/x/net v0.21.0 // indirect
 )
@@ -15,31 +15,68 @@
 package goenv
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/moisespsena-go/error-wrap"
 	"github.com/phayes/permbits"
 )
 
 type GoEnv struct {
-	DbDir string
+	DbDir  string
+	Config *Config
+	// AutoUpdate regenerates the outdated activate scripts on activation.
+	AutoUpdate bool
+	// LockTimeout is the maximum wait of locks held by other processes. Zero
+	// fails if held and negative waits forever.
+	LockTimeout time.Duration
+	// Logger receives the operation events. Defaults to NopLogger.
+	Logger Logger
+
+	locks *lockSet
 }
 
-func NewGoEnv(dbDir string, check bool) (env *GoEnv, err error) {
+func NewGoEnv(dbDir string, check bool, opts ...Option) (env *GoEnv, err error) {
 	ok, err := IsDir(dbDir)
 	if err != nil {
 		return nil, err
 	}
 	if check && !ok {
-		return nil, fmt.Errorf("Database %q isn't initialized.", dbDir)
+		return nil, &DBError{dbDir, ErrDBNotInitialized}
+	}
+	config, err := LoadConfig(dbDir)
+	if err != nil {
+		return nil, err
+	}
+	env = &GoEnv{
+		DbDir:       dbDir,
+		Config:      config,
+		AutoUpdate:  config.Bool("activate.auto_update"),
+		LockTimeout: config.Duration("lock.timeout"),
+		Logger:      NopLogger,
 	}
-	return &GoEnv{dbDir}, nil
+	for _, opt := range opts {
+		opt(env)
+	}
+	return env, nil
 }
 
 func (env *GoEnv) Init(name, goroot string) (err error) {
+	env, unlock, err := env.LockEnv(false, name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	var ok bool
 	pth := filepath.Join(env.DbDir, name)
 	ok, err = IsDir(pth, "src")
@@ -57,7 +94,20 @@ func (env *GoEnv) Init(name, goroot string) (err error) {
 		return err
 	}
 
-	err = env.CreateActivate(pth, "")
+	if goroot == "" {
+		// preserve the Go version binding on update
+		if ok, err = IsFile(pth, "activate"); err != nil {
+			return err
+		} else if ok {
+			b, err := readGoVersionBinding(pth)
+			if err != nil {
+				return err
+			}
+			goroot = b.activateRoot()
+		}
+	}
+
+	err = env.CreateActivate(pth, goroot)
 	if err != nil {
 		return err
 	}
@@ -67,14 +117,14 @@ func (env *GoEnv) Init(name, goroot string) (err error) {
 func (env *GoEnv) Ls() (names []string, err error) {
 	files, err := ioutil.ReadDir(env.DbDir)
 	if err != nil {
-		return nil, fmt.Errorf("'%v': %v", env.DbDir, err)
+		return nil, fmt.Errorf("'%v': %w", env.DbDir, err)
 	}
 	for _, f := range files {
 		if f.IsDir() && f.Name()[0] != '.' {
 			activatePth := filepath.Join(env.DbDir, f.Name(), "activate")
 			hasActivate, err := IsFile(activatePth)
 			if err != nil {
-				return nil, fmt.Errorf("'%v': %v", activatePth, err)
+				return nil, fmt.Errorf("'%v': %w", activatePth, err)
 			}
 			if hasActivate {
 				names = append(names, f.Name())
@@ -89,54 +139,47 @@ func (env *GoEnv) ActivateCode(name string) (string, error) {
 		filepath.Join(env.DbDir, name, "activate")), nil
 }
 
+// GetCheck returns the path of enviroment. The errors are *EnvError: if
+// doesn't exists, of ErrEnvNotFound, and if isn't enviroment, of ErrNotEnv.
 func (env *GoEnv) GetCheck(name string) (pth string, err error) {
-	var exists bool
-	pth = filepath.Join(env.DbDir, name)
-	exists, err = IsDir(pth)
-	if err != nil {
-		return "", fmt.Errorf("%v: %v", pth, err)
-	}
-	if !exists {
-		return "", fmt.Errorf("%v: %v", pth, os.ErrNotExist)
-	}
-
-	exists, err = IsFile(pth, "activate")
-	if err != nil {
-		return "", fmt.Errorf("%v: %v", filepath.Join(pth, "activate"), err)
-	}
-	if !exists {
-		return "", fmt.Errorf("%v is not GoLang enviroment.", pth)
-	}
-	return
+	return env.GetPath(name, true)
 }
 
+// GetPath returns the path of enviroment. If require is true, the errors are
+// as of GetCheck.
 func (env *GoEnv) GetPath(name string, require bool) (pth string, err error) {
 	var exists bool
 	pth = filepath.Join(env.DbDir, name)
 	exists, err = IsDir(pth)
 	if err != nil {
-		return "", fmt.Errorf("%v: %v", pth, err)
+		return "", &EnvError{name, pth, err}
 	}
 	if !exists {
 		if require {
-			return "", fmt.Errorf("%v: %v", pth, os.ErrNotExist)
+			return "", &EnvError{name, pth, ErrEnvNotFound}
 		}
 		return
 	}
 
 	exists, err = IsFile(pth, "activate")
 	if err != nil {
-		return "", fmt.Errorf("%v: %v", filepath.Join(pth, "activate"), err)
+		return "", &EnvError{name, pth, err}
 	}
 	if !exists {
 		if require {
-			return "", fmt.Errorf("%v is not GoLang enviroment.", pth)
+			return "", &EnvError{name, pth, ErrNotEnv}
 		}
 	}
 	return
 }
 
 func (env *GoEnv) Rm(name string, delete bool) (string, error) {
+	env, unlock, err := env.LockEnv(false, name)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
 	var exists bool
 	pth, err := env.GetCheck(name)
 
@@ -157,7 +200,7 @@ func (env *GoEnv) Rm(name string, delete bool) (string, error) {
 
 	if !exists {
 		if err = MkdirAll(trashDir); err != nil {
-			return "", fmt.Errorf("%v: %v", trashDir, err)
+			return "", fmt.Errorf("%v: %w", trashDir, err)
 		}
 	}
 
@@ -167,10 +210,57 @@ func (env *GoEnv) Rm(name string, delete bool) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if err = env.PurgeTrash(); err != nil {
+		return newPth, err
+	}
 	return newPth, nil
 }
 
+// PurgeTrash removes the enviroments moved to trash before the
+// trash.retention config duration. Zero retention keeps all.
+func (env *GoEnv) PurgeTrash() error {
+	env, unlock, err := env.lock(LOCK_TRASH, false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	retention := env.Config.Duration("trash.retention")
+	if retention <= 0 {
+		return nil
+	}
+	trashDir := filepath.Join(env.DbDir, ".trash")
+	items, err := ioutil.ReadDir(trashDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, f := range items {
+		// the name suffix is the TimeString of removal
+		i := strings.LastIndexByte(f.Name(), '_')
+		if i < 0 || len(f.Name())-i-1 < 14 {
+			continue
+		}
+		removedAt, err := time.ParseInLocation("20060102150405", f.Name()[i+1:i+15], time.Local)
+		if err != nil || time.Since(removedAt) < retention {
+			continue
+		}
+		if err = os.RemoveAll(filepath.Join(trashDir, f.Name())); err != nil {
+			return errwrap.Wrap(err, "Purge %q from trash", f.Name())
+		}
+	}
+	return nil
+}
+
 func (env *GoEnv) SetGoVersion(envName, goRoot string) error {
+	env, unlock, err := env.LockEnv(false, envName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	pth, err := env.GetPath(envName, true)
 	if err != nil {
 		return err
@@ -187,18 +277,195 @@ func (env *GoEnv) CreateActivate(pth, goRoot string) error {
 	perms.SetUserExecute(false)
 	perms.SetOtherExecute(false)
 
-	var data = fmt.Sprintf("export GOENVROOT=$(goenv db)\nexport GOENVNAME=%q\n", filepath.Base(pth))
+	data, err := env.activateScript(pth, goRoot)
+	if err != nil {
+		return err
+	}
+	p := filepath.Join(pth, "activate")
+	err = ioutil.WriteFile(p, []byte(data), os.FileMode(perms))
+	if err != nil {
+		return fmt.Errorf("Create file %q failed: %w", p, err)
+	}
+	return nil
+}
+
+// ACTIVATE_VERSION is the version of activate script template, stamped into
+// the scripts header. Increment it when the activate script changes.
+const ACTIVATE_VERSION = 3
+
+// activateTemplate returns the activate script template with the prompt of
+// config.
+func (env *GoEnv) activateTemplate() string {
+	return strings.Replace(activateData, `"[go:$GOENVNAME] $PS1"`, promptWord(env.Config.String("prompt.format"))+`"$PS1"`, 1)
+}
+
+// promptWord returns the prompt format as shell word. The format is quoted,
+// except the $GOENVNAME variable.
+func promptWord(format string) (word string) {
+	for i, part := range strings.Split(strings.Replace(format, "${GOENVNAME}", "$GOENVNAME", -1), "$GOENVNAME") {
+		if i > 0 {
+			word += `"$GOENVNAME"`
+		}
+		if part != "" {
+			word += shellQuote(part)
+		}
+	}
+	return
+}
+
+// activateStamp returns the header line of activate scripts with template
+// version and checksum. The checksum also covers the module cache lines,
+// which depend on cache.mode config.
+func (env *GoEnv) activateStamp(modCache string) string {
+	sum := sha256.Sum256([]byte(env.activateTemplate() + modCache))
+	return fmt.Sprintf("# goenv activate v%d sha256:%s\n", ACTIVATE_VERSION, hex.EncodeToString(sum[:]))
+}
+
+// activateScript returns the activate script contents of enviroment dir pth.
+// The enviroment variables of ENV_SETTINGS_NAME settings file are exported.
+// The previous values of exported variables are restored by
+// goenv-deactivate.
+func (env *GoEnv) activateScript(pth, goRoot string) (string, error) {
+	var (
+		data    string
+		names   = []string{"GOENVROOT", "GOENVNAME", "GOPATH", "GOMODCACHE"}
+		exports = fmt.Sprintf("export GOENVROOT=$(goenv db)\nexport GOENVNAME=%s\n", shellQuote(filepath.Base(pth)))
+	)
 
 	if goRoot != "" {
-		data += fmt.Sprintf("export GOROOT=%q\nexport PATH=\"$GOROOT/bin:$PATH\"\n", goRoot)
+		names = append(names, "GOROOT")
+		exports += fmt.Sprintf("export GOROOT=%s\nexport PATH=\"$GOROOT/bin:$PATH\"\n", rootWord(goRoot))
 	}
 
-	data += activateData
+	modCache, err := env.modCacheActivate(pth)
+	if err != nil {
+		return "", err
+	}
+	exports += modCache
 
-	p := filepath.Join(pth, "activate")
-	err = ioutil.WriteFile(p, []byte(data), os.FileMode(perms))
+	vars, err := readEnvSettings(pth)
 	if err != nil {
-		return fmt.Errorf("Create file %q failed: %v", p, err)
+		return "", err
 	}
-	return nil
+	for _, v := range vars {
+		names = append(names, v[0])
+		exports += fmt.Sprintf("export %s=%s\n", v[0], shellQuote(v[1]))
+	}
+
+	var restore string
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		data += fmt.Sprintf("if [ -n \"${%[1]s+x}\" ]; then export _GOENV_OLD_%[1]s=\"$%[1]s\"; else unset _GOENV_OLD_%[1]s; fi\n", name)
+		restore += fmt.Sprintf("\tif [ -n \"${_GOENV_OLD_%[1]s+x}\" ]; then export %[1]s=\"$_GOENV_OLD_%[1]s\"; unset _GOENV_OLD_%[1]s; else unset %[1]s; fi\n", name)
+	}
+
+	data = env.activateStamp(modCache) + data + "export OLDPATH=\"$PATH\"\n" + exports
+	return data + strings.Replace(env.activateTemplate(), "\tunalias gcd\n", restore+"\tunalias gcd\n", 1), nil
+}
+
+// rootWord returns the GOROOT as shell word. The $GOENVROOT prefix of
+// versions installed into database is expanded.
+func rootWord(goRoot string) string {
+	if strings.HasPrefix(goRoot, "$GOENVROOT") {
+		if rest := strings.TrimPrefix(goRoot, "$GOENVROOT"); rest != "" {
+			return `"$GOENVROOT"` + shellQuote(rest)
+		}
+		return `"$GOENVROOT"`
+	}
+	return shellQuote(goRoot)
+}
+
+// ActivateOutdated reports whether the activate script of enviroment was
+// created from an older template or module cache mode.
+func (env *GoEnv) ActivateOutdated(name string) (bool, error) {
+	pth, err := env.GetCheck(name)
+	if err != nil {
+		return false, err
+	}
+	f, err := os.Open(filepath.Join(pth, "activate"))
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	modCache, err := env.modCacheActivate(pth)
+	if err != nil {
+		return false, err
+	}
+	stamp := env.activateStamp(modCache)
+	header := make([]byte, len(stamp))
+	if _, err = io.ReadFull(f, header); err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false, errwrap.Wrap(err, "Read %q", f.Name())
+	}
+	return string(header) != stamp, nil
+}
+
+// Update regenerates the activate script of enviroment, preserving the Go
+// version binding. Returns true if the script was changed.
+func (env *GoEnv) Update(name string) (changed bool, err error) {
+	env, unlock, err := env.LockEnv(false, name)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	p := filepath.Join(env.DbDir, name, "activate")
+	old, err := ioutil.ReadFile(p)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if err = env.Init(name, ""); err != nil {
+		return false, err
+	}
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(old, data), nil
+}
+
+// GoRoot returns the GOROOT bound to the enviroment by its activate script.
+func (env *GoEnv) GoRoot(name string) (goRoot string, err error) {
+	pth, err := env.GetCheck(name)
+	if err != nil {
+		return "", err
+	}
+	return readActivateGoRoot(filepath.Join(pth, "activate"))
+}
+
+func readActivateGoRoot(pth string) (goRoot string, err error) {
+	lines, err := readLines(pth)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(line, "export GOROOT=") {
+			value := strings.TrimPrefix(line, "export GOROOT=")
+			// the older scripts are quoted by strconv.Quote
+			if goRoot, err = strconv.Unquote(value); err == nil {
+				return goRoot, nil
+			}
+			if goRoot, err = shellUnquote(value); err != nil {
+				return value, nil
+			}
+			return goRoot, nil
+		}
+	}
+	return "", nil
+}
+
+// GoVersionName returns the name of installed Go version bound to the
+// enviroment, or empty string if it uses the system Go.
+func (env *GoEnv) GoVersionName(name string) (string, error) {
+	b, err := env.GoVersionBinding(name)
+	if err != nil {
+		return "", err
+	}
+	if b.IsSystem() {
+		return "", nil
+	}
+	return b.Version, nil
 }
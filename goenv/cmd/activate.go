@@ -33,10 +33,21 @@ Examples:
 		if err != nil {
 			return err
 		}
-		return env.ActivateCode(args[0])
+		if cmd.Flags().Changed("auto-update") {
+			if env.Env.AutoUpdate, err = cmd.Flags().GetBool("auto-update"); err != nil {
+				return err
+			}
+		}
+		if err = env.ActivateCode(args[0]); err != nil {
+			return err
+		}
+		warnGoMod(env.Env, args[0])
+		return nil
 	},
 }
 
 func init() {
+	activateCmd.Flags().Bool("auto-update", false,
+		"Regenerate the outdated activate script. Defaults to activate.auto_update config.")
 	rootCmd.AddCommand(activateCmd)
 }
@@ -0,0 +1,113 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply -f FILE",
+	Short: "Reconcile the enviroments with spec file.",
+	Long: `Reconcile the enviroments of current database with spec file (goenv.yaml).
+
+Creates the missing enviroments, sets the Go version, rewrites the env vars and
+backup excludes, clones the missing repos into src and installs the missing
+tools. Applying the same spec again does nothing.
+
+  environments:
+    - name: web
+      go: "1.22"
+      env:
+        CGO_ENABLED: "0"
+      repos:
+        - url: https://github.com/me/web.git
+          ref: main
+      tools:
+        - golang.org/x/tools/cmd/goimports@v0.20.0
+      exclude:
+        - "*.log"
+
+Examples:
+  $ goenv apply -f goenv.yaml --diff
+  $ goenv apply -f goenv.yaml --prune
+  $ goenv export web | goenv -d ~/other-db apply -f -
+`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := cmd.Flags().GetString("file")
+		if err != nil {
+			return err
+		}
+		diff, err := cmd.Flags().GetBool("diff")
+		if err != nil {
+			return err
+		}
+		prune, err := cmd.Flags().GetBool("prune")
+		if err != nil {
+			return err
+		}
+
+		var reader io.Reader = os.Stdin
+		if file != "-" {
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			reader = f
+		}
+		spec, err := goenv.ReadSpec(reader)
+		if err != nil {
+			return err
+		}
+
+		env, err := goenv.NewGoEnv(db, false, envOptions...)
+		if err != nil {
+			return err
+		}
+		if err = initDB(env); err != nil {
+			return err
+		}
+		changes, err := env.PlanSpec(spec, prune)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			fmt.Println("Nothing to do.")
+			return nil
+		}
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+		if diff {
+			return nil
+		}
+		return env.ApplySpec(changes, os.Stdout, os.Stderr)
+	},
+}
+
+func init() {
+	applyCmd.Flags().StringP("file", "f", "goenv.yaml", "Spec file. Use - for STDIN.")
+	applyCmd.Flags().Bool("diff", false, "Print the changes without apply.")
+	applyCmd.Flags().Bool("prune", false, "Remove (move to trash) the enviroments not specified.")
+	rootCmd.AddCommand(applyCmd)
+}
@@ -15,6 +15,7 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/moisespsena-go/error-wrap"
@@ -43,6 +44,21 @@ Exclude patterns from backup:
   $ ENV_PATH=$(goenv path teste)
   $ mkdir $ENV_PATH/.goenv_settings
   $ echo ".git\nnode_modules\n*.swp" > $ENV_PATH/.goenv_settings/backup_exclude
+
+Backup to store (see 'goenv backups --help' for supported stores):
+
+  $ goenv backup teste s3://my-bucket/goenv
+  $ goenv backup teste sftp://backup.example.com/goenv/teste.tar.gz
+
+Encrypt the backup using age (https://age-encryption.org):
+  With passphrase (read from GOENV_PASSPHRASE enviroment variable or prompt):
+
+  $ goenv backup --encrypt teste target.tar.gz.age
+
+  With recipients:
+
+  $ goenv backup -r age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p teste
+  $ goenv backup -R ~/.goenv-recipients teste
 `,
 	Args: func(cmd *cobra.Command, args []string) error {
 		err := cobra.MinimumNArgs(1)(cmd, args)
@@ -70,6 +86,18 @@ Exclude patterns from backup:
 			}
 		}
 
+		if options.Codec, err = cmd.PersistentFlags().GetString("codec"); err != nil {
+			return err
+		}
+
+		if err = backupEncryption(cmd, options); err != nil {
+			return err
+		}
+
+		if options.Progress, err = progressReporter(cmd, false); err != nil {
+			return err
+		}
+
 		if len(args) == 1 {
 			options.DefaultBackup = true
 		} else if args[1] == "-" {
@@ -78,12 +106,70 @@ Exclude patterns from backup:
 			options.Target = args[1]
 		}
 
-		return env.Backup(args[0], options)
+		return env.BackupContext(ctx, args[0], options)
 	},
 }
 
 func init() {
 	backupCmd.PersistentFlags().StringSliceP("exclude", "e", nil,
 		"Excludes using GLOB. See https://github.com/gobwas/glob for patthern help.")
+	backupCmd.PersistentFlags().BoolP("encrypt", "E", false,
+		"Encrypt using passphrase if recipients isn't defined.")
+	backupCmd.PersistentFlags().StringSliceP("recipient", "r", nil,
+		"Encrypt to the age recipient (age1...).")
+	backupCmd.PersistentFlags().StringSliceP("recipients-file", "R", nil,
+		"Encrypt to the age recipients listed at file.")
+	backupCmd.PersistentFlags().String("codec", "",
+		"Archive codec: gzip or tar (uncompressed). Defaults to backup.codec config.")
+	addProgressFlag(backupCmd)
 	rootCmd.AddCommand(backupCmd)
 }
+
+func backupEncryption(cmd *cobra.Command, options *goenv.BackupOptions) (err error) {
+	flags := cmd.PersistentFlags()
+	encrypt, err := flags.GetBool("encrypt")
+	if err != nil {
+		return err
+	}
+	recipients, err := flags.GetStringSlice("recipient")
+	if err != nil {
+		return err
+	}
+	if options.Recipients, err = goenv.ParseRecipients(recipients...); err != nil {
+		return err
+	}
+	files, err := flags.GetStringSlice("recipients-file")
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		recipients, err := goenv.ReadRecipientsFile(f)
+		if err != nil {
+			return err
+		}
+		options.Recipients = append(options.Recipients, recipients...)
+	}
+	if !encrypt || len(options.Recipients) > 0 {
+		return nil
+	}
+
+	passphrase := os.Getenv("GOENV_PASSPHRASE")
+	if passphrase == "" {
+		if passphrase, err = readPassword("Passphrase: "); err != nil {
+			return err
+		}
+		confirmation, err := readPassword("Confirm passphrase: ")
+		if err != nil {
+			return err
+		}
+		if passphrase != confirmation {
+			return fmt.Errorf("Passphrases didn't match.")
+		}
+	}
+	recipient, err := goenv.PassphraseRecipient(passphrase)
+	if err != nil {
+		return err
+	}
+	options.Recipients = append(options.Recipients, recipient)
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/dustin/go-humanize"
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var backupsCmd = &cobra.Command{
+	Use:   "backups [STORE_URL]",
+	Short: "List backup files.",
+	Long: `List backup files of the default backup directory or of the STORE_URL.
+
+Stores:
+  local directory: file:///path/to/dir
+  S3 compatible:   s3://BUCKET/PREFIX[?endpoint=URL&region=REGION]
+  SFTP:            sftp://[USER@]HOST[:PORT]/PATH
+  WebDAV:          webdav://[USER[:PASSWORD]@]HOST/PATH (webdavs:// for HTTPS)
+
+Examples:
+  $ goenv backups
+  $ goenv backups s3://my-bucket/goenv
+  $ goenv backups sftp://backup.example.com/goenv
+`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openBackupStore(args)
+		if err != nil {
+			return err
+		}
+		defer goenv.CloseBackupStore(store)
+
+		items, err := store.List("")
+		if err != nil {
+			return err
+		}
+		fmt.Println(pad("Size", 12), pad("Modified", 20), "Name")
+		for _, item := range items {
+			fmt.Println(pad(humanize.Bytes(uint64(item.Size)), 12),
+				pad(item.ModTime.Local().Format("2006-01-02 15:04:05"), 20), item.Name)
+		}
+		return nil
+	},
+}
+
+func openBackupStore(args []string) (goenv.BackupStore, error) {
+	if len(args) == 0 || args[0] == "" {
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("New Env: %w", err)
+		}
+		return goenv.NewDirBackupStore(filepath.Join(env.DbDir, ".backup")), nil
+	}
+	store, _, err := goenv.OpenBackupStore(args[0])
+	return store, err
+}
+
+func init() {
+	rootCmd.AddCommand(backupsCmd)
+}
@@ -0,0 +1,57 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var backupsRmCmd = &cobra.Command{
+	Use:   "rm [STORE_URL] NAME...",
+	Short: "Remove backup files.",
+	Long: `Remove backup files of the default backup directory or of the STORE_URL.
+
+Examples:
+  $ goenv backups rm env1/env1_20191010101010.tar.gz
+  $ goenv backups rm s3://my-bucket/goenv env1_20191010101010.tar.gz
+`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var storeArgs []string
+		if goenv.IsBackupStoreURL(args[0]) {
+			storeArgs, args = args[0:1], args[1:]
+		}
+		store, err := openBackupStore(storeArgs)
+		if err != nil {
+			return err
+		}
+		defer goenv.CloseBackupStore(store)
+
+		for _, name := range args {
+			if err = store.Delete(name); err != nil {
+				return fmt.Errorf("Remove %q: %w", name, err)
+			}
+			fmt.Printf("%q removed.\n", name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	backupsCmd.AddCommand(backupsRmCmd)
+}
@@ -0,0 +1,33 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the module caches of enviroments",
+	Long: `Manage the module caches of enviroments.
+
+Each enviroment uses the isolated module cache $GOPATH/pkg/mod, or the shared
+$GOENVROOT/.cache/mod, exported as GOMODCACHE by activate script. The mode is
+set per enviroment with 'goenv cache mode', and defaults to cache.mode config.`,
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,70 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var cacheCleanCmd = &cobra.Command{
+	Use:   "clean [NAME...]",
+	Short: "Remove the module caches",
+	Long: `Remove the isolated module caches of enviroments and, with --shared, the shared
+module cache. With --all, removes the isolated caches of all enviroments.
+
+Examples:
+  $ goenv cache clean e1 e2
+  $ goenv cache clean --shared
+  $ goenv cache clean --all --shared
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		shared, err := cmd.Flags().GetBool("shared")
+		if err != nil {
+			return err
+		}
+		all, err := cmd.Flags().GetBool("all")
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 && !all && !shared {
+			return fmt.Errorf("No enviroment name informed. Use --all or --shared.")
+		}
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return err
+		}
+		if all {
+			if args, err = env.Ls(); err != nil {
+				return err
+			}
+		}
+		removed, err := env.CleanModCache(shared, args...)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s removed.\n", humanize.Bytes(uint64(removed)))
+		return nil
+	},
+}
+
+func init() {
+	cacheCleanCmd.Flags().BoolP("shared", "s", false, "Remove the shared module cache.")
+	cacheCleanCmd.Flags().BoolP("all", "a", false, "Remove the isolated module caches of all enviroments.")
+	cacheCmd.AddCommand(cacheCleanCmd)
+}
@@ -0,0 +1,54 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var cacheDuCmd = &cobra.Command{
+	Use:   "du [NAME...]",
+	Short: "Print the disk usage of shared and isolated module caches",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return err
+		}
+		usage, err := env.ModCacheUsage(args...)
+		if err != nil {
+			return err
+		}
+		var total int64
+		fmt.Println(pad("Name", 20), pad("Mode"), pad("Size"), "Files")
+		for _, u := range usage {
+			name := u.Name
+			if name == "" {
+				name = "(shared)"
+			}
+			total += u.Size
+			fmt.Println(pad(name, 20), pad(u.Mode), pad(humanize.Bytes(uint64(u.Size))), u.Files)
+		}
+		fmt.Println(pad("TOTAL", 20), pad(""), humanize.Bytes(uint64(total)))
+		return nil
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheDuCmd)
+}
@@ -0,0 +1,55 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var cacheMigrateCmd = &cobra.Command{
+	Use:   "migrate [NAME...]",
+	Short: "Move the isolated module caches into the shared cache",
+	Long: `Move the isolated module caches of enviroments into the shared cache, removing
+the files already cached, and set the enviroments to use the shared cache.
+Without NAME, migrates all enviroments.`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			if args, err = env.Ls(); err != nil {
+				return err
+			}
+		}
+		result, err := env.MigrateModCache(args...)
+		if result != nil {
+			for _, name := range result.Envs {
+				fmt.Printf("%q migrated.\n", name)
+			}
+			fmt.Printf("%s moved, %s deduplicated.\n",
+				humanize.Bytes(uint64(result.Moved)), humanize.Bytes(uint64(result.Deduplicated)))
+		}
+		return err
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheMigrateCmd)
+}
@@ -0,0 +1,54 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var cacheModeCmd = &cobra.Command{
+	Use:   "mode NAME [isolated|shared]",
+	Args:  cobra.RangeArgs(1, 2),
+	Short: "Print or set the module cache mode of enviroment",
+	Long: `Print or set the module cache mode of enviroment. The existing cache isn't
+moved, see 'goenv cache migrate'.
+
+Examples:
+  $ goenv cache mode e1
+  $ goenv cache mode e1 shared
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return err
+		}
+		if len(args) == 2 {
+			return env.SetModCacheMode(args[0], args[1])
+		}
+		mode, err := env.ModCacheMode(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(mode)
+		return nil
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheModeCmd)
+}
@@ -0,0 +1,133 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/moisespsena-go/error-wrap"
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the goenv configuration.",
+	Long: `Manage the goenv configuration.
+The configuration layers are, from highest to lowest priority:
+  - command line flags
+  - enviroment variables (GOENV_<KEY>, as GOENV_BACKUP_CODEC)
+  - database config ($GOENVROOT/config.toml)
+  - user config ($XDG_CONFIG_HOME/goenv/config.toml)
+  - system config (/etc/goenv/config.toml)
+`,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List config keys with values and sources.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, err := goenv.NewGoEnv(db, false, envOptions...)
+		if err != nil {
+			return fmt.Errorf("New Env: %w", err)
+		}
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		for _, key := range goenv.ConfigKeys {
+			value, source := env.Config.Lookup(key.Name)
+			fmt.Printf("%s = %q (%s)\n", key.Name, value, source)
+			if verbose {
+				fmt.Printf("    %s\n", key.Description)
+			}
+		}
+		return nil
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Print the config value of KEY.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if goenv.GetConfigKey(args[0]) == nil {
+			return fmt.Errorf("Unknown config key %q.", args[0])
+		}
+		env, err := goenv.NewGoEnv(db, false, envOptions...)
+		if err != nil {
+			return fmt.Errorf("New Env: %w", err)
+		}
+		fmt.Println(env.Config.String(args[0]))
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set KEY [VALUE]",
+	Short: "Set the config value of KEY.",
+	Long: `Set the config value of KEY.
+List values are separated by comma.
+
+Examples:
+  $ goenv config set go.default go1.22.3
+  $ goenv config set --layer user versions.mirrors https://mirror.example.com/go
+  $ goenv config set --unset backup.retention
+`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := goenv.GetConfigKey(args[0])
+		if key == nil {
+			return fmt.Errorf("Unknown config key %q.", args[0])
+		}
+		flags := cmd.Flags()
+		unset, _ := flags.GetBool("unset")
+		layer, _ := flags.GetString("layer")
+		if key.Name == "db" && layer == "db" {
+			return fmt.Errorf("The db key is used only from user and system configs.")
+		}
+		if !unset && len(args) != 2 {
+			return fmt.Errorf("No value informed.")
+		}
+
+		env, err := goenv.NewGoEnv(db, false, envOptions...)
+		if err != nil {
+			return fmt.Errorf("New Env: %w", err)
+		}
+		f := env.Config.File(layer)
+		if f == nil {
+			return fmt.Errorf("Invalid config layer %q.", layer)
+		}
+		if f.Path == "" {
+			return fmt.Errorf("Config file of layer %q isn't defined.", layer)
+		}
+		if unset {
+			f.Set(key.Name, nil)
+		} else {
+			value, err := key.Parse(args[1])
+			if err != nil {
+				return errwrap.Wrap(err, "Value of %q", key.Name)
+			}
+			f.Set(key.Name, value)
+		}
+		return f.Save()
+	},
+}
+
+func init() {
+	configListCmd.Flags().BoolP("verbose", "v", false, "Print the keys descriptions.")
+	configSetCmd.Flags().String("layer", "db", "Config layer: db, user or system.")
+	configSetCmd.Flags().BoolP("unset", "u", false, "Remove the KEY from config layer.")
+	configCmd.AddCommand(configListCmd, configGetCmd, configSetCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -25,6 +25,9 @@ var dbCmd = &cobra.Command{
 	Short: "Returns the current database path.",
 	Long: `Returns the current database path.
 
+For manage the database, see 'goenv db init', 'goenv db info' and
+'goenv db migrate'.
+
 For change current database path:
 
 With command paramenter
@@ -50,6 +53,7 @@ or
 
 	GOENVDB=~/custom-db goenv args...
 `,
+	Annotations: skipDBFormat,
 	Run: func(cmd *cobra.Command, args []string) {
 		os.Stdout.WriteString(db + "\n")
 		os.Stdout.Sync()
@@ -0,0 +1,94 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/dustin/go-humanize"
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var dbInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Print the format, counts and sizes of database",
+	Long: `Print the format version, the counts of enviroments, versions, backups, trash
+items and templates, and the disk usage of database.
+
+Examples:
+  $ goenv db info
+  $ goenv db info -o json
+`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	Annotations:  skipDBFormat,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		if output != "text" && output != "json" {
+			return fmt.Errorf("Invalid output %q. Use text or json.", output)
+		}
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return err
+		}
+		info, err := env.DBInfo()
+		if err != nil {
+			return err
+		}
+
+		if output == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(info)
+		}
+
+		format := fmt.Sprint(info.Format)
+		if info.Outdated {
+			format += fmt.Sprintf(" (outdated, current is %d)", goenv.DB_FORMAT)
+		} else if info.Format > goenv.DB_FORMAT {
+			format += fmt.Sprintf(" (newer than supported %d)", goenv.DB_FORMAT)
+		}
+		fmt.Println(pad("Path:", 16), info.Dir)
+		fmt.Println(pad("Format:", 16), format)
+		fmt.Println(pad("Enviroments:", 16), info.Environments)
+		fmt.Println(pad("Versions:", 16), info.Versions)
+		fmt.Println(pad("Backups:", 16), info.Backups)
+		fmt.Println(pad("Trash:", 16), info.Trash)
+		fmt.Println(pad("Templates:", 16), info.Templates)
+		fmt.Println("Sizes:")
+		var names []string
+		for name := range info.Sizes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Println(" ", pad(name, 14), humanize.Bytes(uint64(info.Sizes[name])))
+		}
+		fmt.Println(" ", pad("total", 14), humanize.Bytes(uint64(info.Total)))
+		return nil
+	},
+}
+
+func init() {
+	dbInfoCmd.Flags().StringP("output", "o", "text", "Output format: text or json.")
+	dbCmd.AddCommand(dbInfoCmd)
+}
@@ -0,0 +1,53 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var dbInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create the database layout and format file",
+	Long: `Create the database layout and format file. If the database is initialized,
+does nothing. The databases created by older releases are upgraded with
+'goenv db migrate'.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	Annotations:  skipDBFormat,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, err := goenv.NewGoEnv(db, false, envOptions...)
+		if err != nil {
+			return err
+		}
+		created, err := env.InitDB()
+		if err != nil {
+			return err
+		}
+		if created {
+			fmt.Printf("Database %q initialized.\n", db)
+		} else {
+			fmt.Printf("Database %q is already initialized.\n", db)
+		}
+		return nil
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(dbInitCmd)
+}
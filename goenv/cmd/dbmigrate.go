@@ -0,0 +1,58 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the database layout to current format",
+	Long: `Upgrade the database layout to current format, running the migrations newer
+than the database format:
+` + dbMigrationsHelp(),
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	Annotations:  skipDBFormat,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return err
+		}
+		applied, err := env.MigrateDB()
+		for _, m := range applied {
+			fmt.Printf("Migrated to format %d: %s.\n", m.Format, m.Description)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("Database is up to date.")
+		}
+		return err
+	},
+}
+
+func dbMigrationsHelp() (s string) {
+	for _, m := range goenv.DBMigrations {
+		s += fmt.Sprintf("\n  %d  %s", m.Format, m.Description)
+	}
+	return
+}
+
+func init() {
+	dbCmd.AddCommand(dbMigrateCmd)
+}
@@ -0,0 +1,97 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor [NAME...]",
+	Short: "Check the enviroments and database integrity.",
+	Long: `Check the enviroments and database integrity.
+Without NAME, checks all enviroments and the database. Exits with non-zero
+status if any warning or error is not fixed.
+
+Examples:
+  $ goenv doctor
+  $ goenv doctor --fix env1
+  $ goenv doctor --list
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if list, _ := cmd.Flags().GetBool("list"); list {
+			for _, check := range goenv.DoctorChecks {
+				fmt.Println(pad(check.Name), check.Description)
+			}
+			return nil
+		}
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return fmt.Errorf("New Env: %w", err)
+		}
+		fix, _ := cmd.Flags().GetBool("fix")
+		if fix {
+			// the fixes wait the running operations
+			var unlock func()
+			if env, unlock, err = env.LockDB(false); err != nil {
+				return err
+			}
+			defer unlock()
+		}
+		findings, err := env.Doctor(args...)
+		if err != nil {
+			return err
+		}
+
+		var problems int
+		for _, f := range findings {
+			where := f.Env
+			if where == "" {
+				where = "[db]"
+			}
+			status := ""
+			if fix && f.Fixable() {
+				if err := f.Fix(); err != nil {
+					status = " (fix failed: " + err.Error() + ")"
+				} else {
+					status = " (fixed)"
+				}
+			} else if f.Fixable() {
+				status = " (fixable)"
+			}
+			fmt.Printf("%s %s %s: %s%s\n", pad(f.Severity.String(), 7), pad(f.Check), where, f.Message, status)
+			if f.Severity > goenv.SeverityInfo && status != " (fixed)" {
+				problems++
+			}
+		}
+		if problems > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d problems found.", problems)
+		}
+		if len(findings) == 0 {
+			fmt.Println("No problems found.")
+		}
+		return nil
+	},
+}
+
+func init() {
+	doctorCmd.Flags().Bool("fix", false, "Fix the fixable problems.")
+	doctorCmd.Flags().BoolP("list", "l", false, "List the checks.")
+	rootCmd.AddCommand(doctorCmd)
+}
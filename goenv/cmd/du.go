@@ -0,0 +1,140 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var duCmd = &cobra.Command{
+	Use:   "du [NAME...]",
+	Short: "Print the disk usage of enviroments and database",
+	Long: `Print the disk usage of enviroments, by top level area (src, pkg/mod,
+pkg/<os_arch>, bin and other). Without NAME, prints all enviroments and the
+database dirs (.backup, .trash, .goversions, .tmp and .cache).
+
+Examples:
+  $ goenv du
+  $ goenv du --sort size
+  $ goenv du e1 e2 -o json
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sortBy, err := cmd.Flags().GetString("sort")
+		if err != nil {
+			return err
+		}
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		if sortBy != "name" && sortBy != "size" {
+			return fmt.Errorf("Invalid sort %q. Use name or size.", sortBy)
+		}
+		if output != "table" && output != "json" {
+			return fmt.Errorf("Invalid output %q. Use table or json.", output)
+		}
+
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return err
+		}
+		result := &duResult{}
+		if result.Environments, err = env.DiskUsage(args...); err != nil {
+			return err
+		}
+		goenv.SortDiskUsage(result.Environments, sortBy == "size")
+		if len(args) == 0 {
+			if result.Database, err = env.DBDiskUsage(); err != nil {
+				return err
+			}
+			goenv.SortDiskUsage(result.Database, sortBy == "size")
+		}
+		for _, u := range append(result.Environments, result.Database...) {
+			result.Total += u.Total
+		}
+
+		if output == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(result)
+		}
+		result.print()
+		return nil
+	},
+}
+
+type duResult struct {
+	Environments []*goenv.DiskUsage `json:"environments"`
+	Database     []*goenv.DiskUsage `json:"database,omitempty"`
+	Total        int64              `json:"total"`
+}
+
+// areas returns the areas of enviroments as src, pkg/mod, pkg/*, bin, other.
+func (r *duResult) areas() []string {
+	var pkgs []string
+	seen := map[string]bool{}
+	for _, u := range r.Environments {
+		for area := range u.Areas {
+			if !seen[area] && strings.HasPrefix(area, "pkg/") && area != "pkg/mod" {
+				pkgs = append(pkgs, area)
+			}
+			seen[area] = true
+		}
+	}
+	sort.Strings(pkgs)
+	return append(append([]string{"src", "pkg/mod"}, pkgs...), "bin", goenv.DU_OTHER)
+}
+
+func (r *duResult) print() {
+	areas := r.areas()
+	header := []string{pad("Name", 20)}
+	widths := make([]int, len(areas))
+	for i, area := range areas {
+		if widths[i] = 12; len(area) >= widths[i] {
+			widths[i] = len(area) + 1
+		}
+		header = append(header, pad(area, widths[i]))
+	}
+	fmt.Println(strings.Join(append(header, "Total"), " "))
+	for _, u := range r.Environments {
+		line := []string{pad(u.Name, 20)}
+		for i, area := range areas {
+			line = append(line, pad(humanize.Bytes(uint64(u.Areas[area])), widths[i]))
+		}
+		fmt.Println(strings.Join(append(line, humanize.Bytes(uint64(u.Total))), " "))
+	}
+	if len(r.Database) > 0 {
+		fmt.Println()
+		for _, u := range r.Database {
+			fmt.Println(pad(u.Name, 20), humanize.Bytes(uint64(u.Total)))
+		}
+	}
+	fmt.Println()
+	fmt.Println(pad("TOTAL", 20), humanize.Bytes(uint64(r.Total)))
+}
+
+func init() {
+	duCmd.Flags().StringP("sort", "s", "name", "Sort by name or size (largest first).")
+	duCmd.Flags().StringP("output", "o", "table", "Output format: table or json.")
+	rootCmd.AddCommand(duCmd)
+}
@@ -0,0 +1,52 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export NAME...",
+	Short: "Print the spec (goenv.yaml) of enviroments.",
+	Long: `Print the spec (goenv.yaml) of enviroments. See apply command.
+
+Examples:
+  $ goenv export env1 env2 > goenv.yaml
+`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return err
+		}
+		spec := &goenv.Spec{}
+		for _, name := range args {
+			e, err := env.ExportSpec(name)
+			if err != nil {
+				return err
+			}
+			spec.Environments = append(spec.Environments, e)
+		}
+		return spec.Write(os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+}
@@ -15,6 +15,8 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/moisespsena-go/goenv"
 	"github.com/spf13/cobra"
 )
@@ -24,9 +26,24 @@ var initCmd = &cobra.Command{
 	Short: "Init new virtual enviroment.",
 	Long: `Init new virtual enviroment on current database.
 
+The GoLang version (as 1.22, 1.22.3 or sys) is installed if missing. Defaults
+to the template version or go.default config.
+
+The templates are directories or archives (.tar.gz or .tar, with single root
+directory) into $GOENVROOT/.templates. The template contents are copied to the
+enviroment, except the optional template.toml file:
+
+  go = "1.22"
+  exclude = ["*.log"]
+  post_init = ["go install golang.org/x/tools/cmd/goimports@latest"]
+
+  [env]
+  CGO_ENABLED = "0"
+
 Examples:
   $ goenv init env1 env2
   $ goenv -d ~/my-goenv init env3 env4
+  $ goenv init env5 --go 1.22 --template web-service
 `,
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -37,11 +54,23 @@ Examples:
 		if err != nil {
 			return err
 		}
+		if err = initDB(env.Env); err != nil {
+			return err
+		}
 
-		return env.Init(args[0])
+		options := &goenv.InitOptions{Stdout: os.Stdout, Stderr: os.Stderr}
+		if options.GoVersion, err = cmd.Flags().GetString("go"); err != nil {
+			return err
+		}
+		if options.Template, err = cmd.Flags().GetString("template"); err != nil {
+			return err
+		}
+		return env.InitWith(options, args...)
 	},
 }
 
 func init() {
+	initCmd.Flags().StringP("go", "g", "", "GoLang version of enviroment.")
+	initCmd.Flags().StringP("template", "t", "", "Template name.")
 	rootCmd.AddCommand(initCmd)
 }
@@ -15,7 +15,7 @@
 package cmd
 
 import (
-	"github.com/moisespsena-go/error-wrap"
+	"fmt"
 	"github.com/moisespsena-go/goenv"
 	"github.com/spf13/cobra"
 	"os"
@@ -42,7 +42,7 @@ var pathCmd = &cobra.Command{
 		for i, name := range args {
 			pth, err = env.Env.GetCheck(name)
 			if err != nil {
-				return errwrap.Wrap(err, "Arg %d: %q", i, name)
+				return fmt.Errorf("Arg %d: %q: %w", i, name, err)
 			}
 
 			if _, err = os.Stdout.WriteString(pth + "\n"); err != nil {
@@ -15,8 +15,11 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"strings"
 
+	"filippo.io/age"
 	"github.com/moisespsena-go/goenv"
 	"github.com/spf13/cobra"
 )
@@ -29,9 +32,20 @@ Examples:
   Restore from file.
   	$ goenv restore env1.tar.gz
 
+  Restore from store (see 'goenv backups --help' for supported stores):
+  	$ goenv restore s3://my-bucket/goenv/env1_20191010101010.tar.gz
+
   Restore from stdout:
   	$ cat backup.tar.gz | goenv restore
 
+  Restore and install the Go version of backup if isn't installed:
+	$ goenv restore -g env1.tar.gz
+
+  Restore encrypted backup:
+	$ goenv restore env1.tar.gz.age
+	$ goenv restore -i ~/.goenv-identity env1.tar.gz.age
+	$ GOENV_PASSPHRASE=secret goenv restore env1.tar.gz.age
+
   Restore with another name:
 	$ goenv restore -n env2 backup.tar.gz
   	$ cat env1.tar.gz | goenvrestore -n env2
@@ -86,8 +100,31 @@ Examples:
 		if err != nil {
 			return err
 		}
+		if options.Progress, err = progressReporter(cmd, options.Verbose); err != nil {
+			return err
+		}
+		identities, err := cmd.PersistentFlags().GetStringSlice("identity")
+		if err != nil {
+			return err
+		}
+		options.Identities = restoreIdentities(identities)
+		installGo, err := cmd.PersistentFlags().GetBool("install-go")
+		if err != nil {
+			return err
+		}
+		options.InstallGoVersion = func(manifest *goenv.BackupManifest) bool {
+			if installGo {
+				return true
+			}
+			if options.Source == "" {
+				fmt.Fprintf(os.Stderr, "Go version %q isn't installed. Use `goenv versions install %s` to install it.\n",
+					manifest.GoVersion, strings.TrimPrefix(manifest.GoVersion, "go"))
+				return false
+			}
+			return confirm(fmt.Sprintf("Go version %q isn't installed. Install it?", manifest.GoVersion))
+		}
 
-		return env.Restore(options)
+		return env.RestoreContext(ctx, options)
 	},
 }
 
@@ -102,7 +139,43 @@ func init() {
 		"Print names while restoring.")
 	restoreCmd.PersistentFlags().BoolP("dry-run", "D", false,
 		"Perform a trial run with no changes made.")
+	restoreCmd.PersistentFlags().BoolP("install-go", "g", false,
+		"Install the Go version of backup if isn't installed, without confirmation.")
 	restoreCmd.PersistentFlags().StringP("name", "n", "",
 		"Name after restored.")
+	restoreCmd.PersistentFlags().StringSliceP("identity", "i", nil,
+		"Decrypt using the age identity file. Defaults to GOENV_IDENTITY enviroment variable.")
+	addProgressFlag(restoreCmd)
 	rootCmd.AddCommand(restoreCmd)
 }
+
+func restoreIdentities(files []string) goenv.IdentitiesFunc {
+	return func() (identities []age.Identity, err error) {
+		if len(files) == 0 {
+			if f := os.Getenv("GOENV_IDENTITY"); f != "" {
+				files = append(files, f)
+			}
+		}
+		for _, f := range files {
+			ids, err := goenv.ReadIdentitiesFile(f)
+			if err != nil {
+				return nil, err
+			}
+			identities = append(identities, ids...)
+		}
+		if len(identities) > 0 {
+			return
+		}
+		passphrase := os.Getenv("GOENV_PASSPHRASE")
+		if passphrase == "" {
+			if passphrase, err = readPassword("Passphrase: "); err != nil {
+				return nil, err
+			}
+		}
+		identity, err := goenv.PassphraseIdentity(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Identity{identity}, nil
+	}
+}
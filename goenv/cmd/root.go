@@ -15,39 +15,156 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/mitchellh/go-homedir"
+	"github.com/moisespsena-go/goenv"
 	"github.com/spf13/cobra"
 )
 
 var db string
 
+// ctx is the context of long operations. It's canceled on SIGINT or SIGTERM.
+var ctx = context.Background()
+
+// envOptions are the options of GoEnv created by commands.
+var envOptions = []goenv.Option{goenv.WithLogger(goenv.NewCmdLogger(os.Stdout, os.Stderr))}
+
 var rootCmd = &cobra.Command{
 	Use:   "goenv",
 	Short: "The virtual enviroments manager for Go!",
+	Long: `The virtual enviroments manager for Go!
+
+Exit status:
+  0    success
+  1    other errors
+  3    database isn't initialized
+  4    enviroment not found
+  5    directory isn't enviroment
+  6    enviroment exists
+  7    GoLang version isn't installed
+  8    lock is held by other process
+  9    database format isn't supported
+  10   GoLang version is used by enviroments
+  11   GoLang version isn't available for download
+  12   database format is outdated (run 'goenv db migrate')
+  130  canceled by SIGINT or SIGTERM`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) (err error) {
-		if db != "" {
-			db, err = homedir.Expand(db)
+		if err = expandDB(); err != nil {
+			return
+		}
+		if cmd.Annotations[skipDBFormatAnnotation] != "" {
+			return
+		}
+		if err = checkDBFormat(); err != nil {
+			cmd.SilenceUsage = true
 		}
 		return
 	},
 }
 
+// skipDBFormatAnnotation is the annotation of commands which don't check the
+// database format, because they handle it by itself.
+const skipDBFormatAnnotation = "goenv:skip-db-format"
+
+// skipDBFormat is the annotations of commands which don't check the database
+// format.
+var skipDBFormat = map[string]string{skipDBFormatAnnotation: "true"}
+
+func expandDB() (err error) {
+	if db != "" {
+		db, err = homedir.Expand(db)
+	}
+	return
+}
+
+// initDB initializes the database of commands which creates enviroments or
+// versions, if it doesn't exists.
+func initDB(env *goenv.GoEnv) error {
+	if ok, err := goenv.IsDir(env.DbDir); err != nil || ok {
+		return err
+	}
+	_, err := env.InitDB()
+	return err
+}
+
+// checkDBFormat fails if the database format isn't supported, and warns if
+// it's outdated.
+func checkDBFormat() error {
+	outdated, err := goenv.CheckDBFormat(db)
+	if err != nil {
+		return err
+	}
+	if outdated {
+		fmt.Fprintf(os.Stderr, "WARNING: database %q format is outdated. Run 'goenv db migrate'.\n", db)
+	}
+	return nil
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	var cancel context.CancelFunc
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		fmt.Fprintln(os.Stderr, "Canceling... (press again to exit now)")
+		rootCmd.SilenceUsage = true
+		cancel()
+		<-signals
+		os.Exit(130)
+	}()
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
+	}
+}
+
+// exitCodes are the exit status of goenv errors.
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{goenv.ErrDBNotInitialized, 3},
+	{goenv.ErrEnvNotFound, 4},
+	{goenv.ErrNotEnv, 5},
+	{goenv.ErrEnvExists, 6},
+	{goenv.ErrVersionNotInstalled, 7},
+	{goenv.ErrLockTimeout, 8},
+	{goenv.ErrDBFormat, 9},
+	{goenv.ErrVersionInUse, 10},
+	{goenv.ErrVersionNotAvailable, 11},
+	{goenv.ErrDBOutdated, 12},
+}
+
+func exitCode(err error) int {
+	if ctx.Err() != nil {
+		return 130
+	}
+	for _, c := range exitCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
 	}
+	return 1
 }
 
 func init() {
-	defaultDb := os.Getenv("GOENVDB")
-	if defaultDb == "" {
+	defaultDb, err := goenv.DefaultDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		defaultDb = "~/.goenv"
 	}
-	rootCmd.PersistentFlags().StringVarP(&db, "db", "d", defaultDb, "Database directory (default is $HOME/.goenv).")
+	rootCmd.PersistentFlags().StringVarP(&db, "db", "d", defaultDb,
+		"Database directory. Defaults to GOENVDB enviroment variable, db config or $HOME/.goenv.")
 }
@@ -0,0 +1,46 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "List enviroment templates of $GOENVROOT/.templates dir.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return err
+		}
+		templates, err := env.Templates()
+		if err != nil {
+			return err
+		}
+		for _, t := range templates {
+			fmt.Println(pad(t.Name, 20), t.Path)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(templatesCmd)
+}
@@ -0,0 +1,51 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var toolsCmd = &cobra.Command{
+	Use:   "tools",
+	Short: "Manage the tools manifest of enviroment",
+	Long: `Manage the tools manifest of enviroment ($GOPATH/.goenv_settings/tools).
+
+The tools are binaries installed into $GOPATH/bin with 'go install' of bound
+toolchain. The enviroment defaults to the active enviroment ($GOENVNAME).`,
+}
+
+func init() {
+	toolsCmd.PersistentFlags().StringP("env", "e", "", "Enviroment name. Defaults to $GOENVNAME.")
+	rootCmd.AddCommand(toolsCmd)
+}
+
+// toolsEnvName returns the --env flag value or the active enviroment.
+func toolsEnvName(cmd *cobra.Command) (string, error) {
+	name, err := cmd.Flags().GetString("env")
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = os.Getenv("GOENVNAME")
+	}
+	if name == "" {
+		return "", fmt.Errorf("No enviroment name informed.")
+	}
+	return name, nil
+}
@@ -0,0 +1,65 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var toolsAddCmd = &cobra.Command{
+	Use:   "add NAME PACKAGE[@VERSION]",
+	Args:  cobra.ExactArgs(2),
+	Short: "Record the tool into manifest of enviroment",
+	Long: `Record the tool into manifest of enviroment, replacing the tool with same
+NAME. VERSION defaults to latest.
+
+Examples:
+  $ goenv tools add golangci-lint github.com/golangci/golangci-lint/cmd/golangci-lint@v1.59.0
+  $ goenv tools add mockgen go.uber.org/mock/mockgen@v0.4.0 --install
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		envName, err := toolsEnvName(cmd)
+		if err != nil {
+			return err
+		}
+		install, err := cmd.Flags().GetBool("install")
+		if err != nil {
+			return err
+		}
+		tool, err := goenv.ParseTool(args[0] + " " + args[1])
+		if err != nil {
+			return err
+		}
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return err
+		}
+		if err = env.AddTool(envName, tool); err != nil {
+			return err
+		}
+		if install {
+			return env.InstallTool(envName, tool, os.Stdout, os.Stderr)
+		}
+		return nil
+	},
+}
+
+func init() {
+	toolsAddCmd.Flags().BoolP("install", "i", false, "Install the tool.")
+	toolsCmd.AddCommand(toolsAddCmd)
+}
@@ -0,0 +1,68 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var toolsLsCmd = &cobra.Command{
+	Use:   "ls",
+	Args:  cobra.NoArgs,
+	Short: "List the tools of manifest with declared and installed versions",
+	Long: `List the tools of manifest with declared and installed versions. The installed
+version is read from build info of binary.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		envName, err := toolsEnvName(cmd)
+		if err != nil {
+			return err
+		}
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return err
+		}
+		status, err := env.ToolsStatus(envName)
+		if err != nil {
+			return err
+		}
+		fmt.Println(pad("Name", 20), pad("Declared", 12), pad("Installed", 12), pad("Status"), "Package")
+		var invalid []*goenv.ToolStatus
+		for _, s := range status {
+			installed, state := s.Installed, "ok"
+			switch {
+			case s.Err != nil:
+				installed, state = "-", "INVALID"
+				invalid = append(invalid, s)
+			case installed == "":
+				installed, state = "-", "MISSING"
+			case !s.Ok():
+				state = "OUTDATED"
+			}
+			fmt.Println(pad(s.Name, 20), pad(s.Version, 12), pad(installed, 12), pad(state), s.Package)
+		}
+		for _, s := range invalid {
+			fmt.Fprintf(os.Stderr, "WARNING: tool %q: %v. Reinstall with 'goenv tools sync'.\n", s.Name, s.Err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	toolsCmd.AddCommand(toolsLsCmd)
+}
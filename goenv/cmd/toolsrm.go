@@ -0,0 +1,41 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var toolsRmCmd = &cobra.Command{
+	Use:   "rm NAME...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "Remove the tools from manifest of enviroment. The binaries are kept",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		envName, err := toolsEnvName(cmd)
+		if err != nil {
+			return err
+		}
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return err
+		}
+		return env.RmTool(envName, args...)
+	},
+}
+
+func init() {
+	toolsCmd.AddCommand(toolsRmCmd)
+}
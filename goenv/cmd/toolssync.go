@@ -0,0 +1,56 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var toolsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Args:  cobra.NoArgs,
+	Short: "Install the tools of manifest into $GOPATH/bin of enviroment",
+	Long: `Install the tools of manifest into $GOPATH/bin of enviroment with the bound
+toolchain. Only the tools missing or with other version than declared are
+installed, unless --force.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		envName, err := toolsEnvName(cmd)
+		if err != nil {
+			return err
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return err
+		}
+		installed, err := env.SyncTools(envName, force, os.Stdout, os.Stderr)
+		for _, t := range installed {
+			fmt.Printf("%s installed.\n", t)
+		}
+		return err
+	},
+}
+
+func init() {
+	toolsSyncCmd.Flags().BoolP("force", "f", false, "Install all tools.")
+	toolsCmd.AddCommand(toolsSyncCmd)
+}
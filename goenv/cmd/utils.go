@@ -15,7 +15,15 @@
 package cmd
 
 import (
+	"bufio"
+	"fmt"
+	"os"
 	"strings"
+	"time"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 func pad(v string, s ...int) string {
@@ -28,3 +36,49 @@ func pad(v string, s ...int) string {
 	}
 	return v + strings.Repeat(" ", l-len(v))
 }
+
+func confirm(question string) bool {
+	fmt.Fprint(os.Stderr, question+" [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+func readPassword(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		tty = os.Stdin
+	} else {
+		defer tty.Close()
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+	data, err := term.ReadPassword(int(tty.Fd()))
+	if err != nil {
+		return "", fmt.Errorf("Read passphrase: %v", err)
+	}
+	return string(data), nil
+}
+
+func addProgressFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolP("progress", "P", false,
+		"Show progress. Enabled by default if STDERR is a terminal.")
+}
+
+func progressReporter(cmd *cobra.Command, verbose bool) (goenv.ProgressFunc, error) {
+	tty := term.IsTerminal(int(os.Stderr.Fd()))
+	enabled := tty && !verbose
+	if flag := cmd.PersistentFlags().Lookup("progress"); flag.Changed {
+		var err error
+		if enabled, err = cmd.PersistentFlags().GetBool("progress"); err != nil {
+			return nil, err
+		}
+	}
+	if !enabled {
+		return nil, nil
+	}
+	return goenv.NewProgressReporter(os.Stderr, tty, 10*time.Second), nil
+}
@@ -0,0 +1,55 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var versionsAddCmd = &cobra.Command{
+	Use:   "add NAME PATH",
+	Args:  cobra.ExactArgs(2),
+	Short: "Register external GoLang toolchain (GOROOT or go binary) as NAME",
+	Long: `Register external GoLang toolchain (GOROOT or go binary) as NAME.
+The toolchain is linked into $GOENVROOT/.goversions dir and can be used by
+'versions set'.
+
+Examples:
+  $ goenv versions add go1.20-distro /usr/lib/go-1.20
+  $ goenv versions add go1.21-sdk ~/sdk/go1.21.5/bin/go
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, err := goenv.NewGoEnv(db, false, envOptions...)
+		if err != nil {
+			return fmt.Errorf("New Env: %w", err)
+		}
+		if err = initDB(env); err != nil {
+			return err
+		}
+		v, err := goenv.NewGoVersions(env).Add(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Println(pad(v.Name), pad(v.BinVersion.Version), v.LinkTarget)
+		return nil
+	},
+}
+
+func init() {
+	versionsCmd.AddCommand(versionsAddCmd)
+}
@@ -0,0 +1,85 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var versionsBuildCmd = &cobra.Command{
+	Use:   "build NAME (--from DIR | --git-ref REF)",
+	Args:  cobra.ExactArgs(1),
+	Short: "Build GoLang version from sources and install it into $GOENVROOT/.goversions dir",
+	Long: `Build GoLang version from sources using make.bash and install it into
+$GOENVROOT/.goversions/NAME dir. The build log is saved to
+$GOENVROOT/.goversions/NAME.build.log.
+
+Examples:
+  $ goenv versions build go1.22-patched --from ~/src/go
+  $ goenv versions build gotip --git-ref master
+  $ goenv versions build go1.21-custom --git-ref release-branch.go1.21 --bootstrap go1.20.14
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		env, err := goenv.NewGoEnv(db, false, envOptions...)
+		if err != nil {
+			return fmt.Errorf("New Env: %w", err)
+		}
+		if err = initDB(env); err != nil {
+			return err
+		}
+		options := &goenv.BuildOptions{}
+		flags := cmd.Flags()
+		if options.SourceDir, err = flags.GetString("from"); err != nil {
+			return
+		}
+		if options.GitRef, err = flags.GetString("git-ref"); err != nil {
+			return
+		}
+		if options.GitURL, err = flags.GetString("git-url"); err != nil {
+			return
+		}
+		if options.Bootstrap, err = flags.GetString("bootstrap"); err != nil {
+			return
+		}
+		verbose, err := flags.GetBool("verbose")
+		if err != nil {
+			return
+		}
+		if verbose {
+			options.Log = os.Stderr
+		}
+		vs := goenv.NewGoVersions(env)
+		fmt.Printf("[%v] Building... (log: %v)\n", args[0], vs.BuildLogPath(args[0]))
+		v, err := vs.BuildContext(ctx, args[0], options)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[%v] Installed on %v (%v)\n", v.Name, v.Root, v.BinVersion.Version)
+		return nil
+	},
+}
+
+func init() {
+	versionsBuildCmd.Flags().String("from", "", "Go source tree directory.")
+	versionsBuildCmd.Flags().String("git-ref", "", "Branch, tag or commit to fetch. Uses --from as repository if defined.")
+	versionsBuildCmd.Flags().String("git-url", goenv.GO_SOURCE_REPOSITORY, "Git repository of Go sources.")
+	versionsBuildCmd.Flags().String("bootstrap", "sys", "Installed version used for bootstrap, or 'sys' for system Go.")
+	versionsBuildCmd.Flags().BoolP("verbose", "v", false, "Print the build log.")
+	versionsCmd.AddCommand(versionsBuildCmd)
+}
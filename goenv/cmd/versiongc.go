@@ -0,0 +1,72 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/dustin/go-humanize"
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var versionsGcCmd = &cobra.Command{
+	Use:   "gc",
+	Args:  cobra.NoArgs,
+	Short: "Remove unused GoLang versions and downloaded archives",
+	Long: `Remove unused GoLang versions and downloaded archives.
+Removes installed versions not used by any enviroment and not marked as kept
+(see 'versions keep'), and the downloaded archives of installed versions.
+The archives of other versions are partial downloads, kept to resume. External
+toolchains (see 'versions add') are never removed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return fmt.Errorf("New Env: %w", err)
+		}
+		vs, err := newGoVersions(cmd, env)
+		if err != nil {
+			return err
+		}
+		options := &goenv.GCOptions{KeepArchives: vs.KeepArchives}
+		options.DryRun, _ = cmd.Flags().GetBool("dry-run")
+		result, err := vs.GC(options)
+		if err != nil {
+			return err
+		}
+		action := "Removed"
+		if options.DryRun {
+			action = "Would remove"
+		}
+		for _, name := range result.Versions {
+			fmt.Println(action, "version", name)
+		}
+		for _, pth := range result.Archives {
+			fmt.Println(action, "archive", filepath.Base(pth))
+		}
+		if options.DryRun {
+			fmt.Println("Reclaimable:", humanize.Bytes(uint64(result.Reclaimed)))
+		} else {
+			fmt.Println("Reclaimed:", humanize.Bytes(uint64(result.Reclaimed)))
+		}
+		return nil
+	},
+}
+
+func init() {
+	versionsGcCmd.Flags().BoolP("dry-run", "n", false, "Show what would be removed without removing anything.")
+	versionsCmd.AddCommand(versionsGcCmd)
+}
@@ -17,7 +17,6 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/moisespsena-go/error-wrap"
 	"github.com/moisespsena-go/goenv"
 	"github.com/spf13/cobra"
 )
@@ -27,12 +26,18 @@ var versionsGetCmd = &cobra.Command{
 	Args:  cobra.MinimumNArgs(1),
 	Short: "Download one or more GoLang versions and save files into $GOENVROOT/.versions dir",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		env, err := goenv.NewGoEnv(db, false)
+		env, err := goenv.NewGoEnv(db, false, envOptions...)
 		if err != nil {
-			return errwrap.Wrap(err, "New Env")
+			return fmt.Errorf("New Env: %w", err)
 		}
-		v := goenv.NewGoVersions(env)
-		items, err := v.Download(args...)
+		if err = initDB(env); err != nil {
+			return err
+		}
+		v, err := newGoVersions(cmd, env)
+		if err != nil {
+			return err
+		}
+		items, err := v.DownloadContext(ctx, args...)
 		if err != nil {
 			return err
 		}
@@ -15,7 +15,7 @@
 package cmd
 
 import (
-	"github.com/moisespsena-go/error-wrap"
+	"fmt"
 	"github.com/moisespsena-go/goenv"
 	"github.com/spf13/cobra"
 )
@@ -25,12 +25,18 @@ var versionsInstallCmd = &cobra.Command{
 	Args:  cobra.MinimumNArgs(1),
 	Short: "Install one or more GoLang versions and save files into $GOENVROOT/.versions dir",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		env, err := goenv.NewGoEnv(db, false)
+		env, err := goenv.NewGoEnv(db, false, envOptions...)
 		if err != nil {
-			return errwrap.Wrap(err, "New Env")
+			return fmt.Errorf("New Env: %w", err)
 		}
-		v := goenv.NewGoVersions(env)
-		_, err = v.Install(args...)
+		if err = initDB(env); err != nil {
+			return err
+		}
+		v, err := newGoVersions(cmd, env)
+		if err != nil {
+			return err
+		}
+		_, err = v.InstallContext(ctx, args...)
 		return err
 	},
 }
@@ -0,0 +1,53 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var versionsKeepCmd = &cobra.Command{
+	Use:   "keep [VERSION...]",
+	Short: "Mark versions as kept, so 'versions gc' doesn't removes them",
+	Long: `Mark versions as kept, so 'versions gc' doesn't removes them.
+Without arguments, lists the kept versions.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, err := goenv.NewGoEnv(db, false, envOptions...)
+		if err != nil {
+			return fmt.Errorf("New Env: %w", err)
+		}
+		vs := goenv.NewGoVersions(env)
+		if len(args) == 0 {
+			names, err := vs.Kept()
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				fmt.Println(name)
+			}
+			return nil
+		}
+		unset, _ := cmd.Flags().GetBool("unset")
+		return vs.Keep(!unset, args...)
+	},
+}
+
+func init() {
+	versionsKeepCmd.Flags().BoolP("unset", "u", false, "Remove the kept mark of versions.")
+	versionsCmd.AddCommand(versionsKeepCmd)
+}
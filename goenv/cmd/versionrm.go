@@ -0,0 +1,56 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var versionsRmCmd = &cobra.Command{
+	Use:   "rm VERSION...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "Remove installed GoLang versions. External toolchains are only unregistered",
+	Long: `Remove installed GoLang versions. External toolchains are only unregistered.
+The versions used by any enviroment aren't removed, unless forced.
+
+Examples:
+  $ goenv versions rm go1.20.1
+  $ goenv versions rm -f go1.20.1
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, err := goenv.NewGoEnv(db, false, envOptions...)
+		if err != nil {
+			return fmt.Errorf("New Env: %w", err)
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+		vs := goenv.NewGoVersions(env)
+		for _, name := range args {
+			if err = vs.Rm(name, force); err != nil {
+				return fmt.Errorf("Remove %q: %w", name, err)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	versionsRmCmd.Flags().BoolP("force", "f", false, "Remove also the versions used by enviroments.")
+	versionsCmd.AddCommand(versionsRmCmd)
+}
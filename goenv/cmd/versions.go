@@ -16,8 +16,9 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
-	"github.com/moisespsena-go/error-wrap"
 	"github.com/moisespsena-go/goenv"
 	"github.com/spf13/cobra"
 )
@@ -26,13 +27,16 @@ var versionsCmd = &cobra.Command{
 	Use:   "versions",
 	Short: "Manage golang binary versions",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		env, err := goenv.NewGoEnv(db, false)
+		env, err := goenv.NewGoEnv(db, false, envOptions...)
 		if err != nil {
-			return errwrap.Wrap(err, "New Env")
+			return fmt.Errorf("New Env: %w", err)
 		}
 
 		v := goenv.NewGoVersions(env)
 		items, err := v.Ls()
+		if err != nil {
+			return err
+		}
 
 		system, err := goenv.GetSystemGoVersion()
 		if err != nil {
@@ -41,22 +45,59 @@ var versionsCmd = &cobra.Command{
 		if system != nil {
 			items = append([]*goenv.GoVersion{system}, items...)
 		}
+
+		usage, err := v.Usage()
 		if err != nil {
 			return err
 		}
-		fmt.Println(pad("Name"), pad("Version"), "Root")
+		for _, name := range usage[goenv.SYS_PIN_VERSION] {
+			usage[goenv.SYS_VERSION] = append(usage[goenv.SYS_VERSION], name+" (pin)")
+		}
+
+		fmt.Println(pad("Name"), pad("Version"), pad("Root", 40), "Enviroments")
 		for _, v := range items {
 			name := v.Name
 			if v.System {
-				name = "sys"
+				name = goenv.SYS_VERSION
 			}
 
-			fmt.Println(pad(name), pad(v.Name), v.Root)
+			root := v.Root
+			if v.LinkTarget != "" {
+				root += " -> " + v.LinkTarget
+			}
+			fmt.Println(pad(name), pad(v.Name), pad(root, 40), strings.Join(usage[name], ", "))
 		}
 		return nil
 	},
 }
 
 func init() {
+	versionsCmd.PersistentFlags().StringSlice("mirror", nil,
+		"Base URL of mirror used to download versions. Defaults to versions.mirrors config.")
+	versionsCmd.PersistentFlags().Int("retries", 3, "Number of download retries.")
+	versionsCmd.PersistentFlags().Duration("retry-backoff", 2*time.Second,
+		"Wait before first download retry. Doubled on each retry.")
+	versionsCmd.PersistentFlags().Bool("keep-archives", false,
+		"Don't remove the downloaded archives after install.")
 	rootCmd.AddCommand(versionsCmd)
 }
+
+func newGoVersions(cmd *cobra.Command, env *goenv.GoEnv) (vs *goenv.GoVersions, err error) {
+	vs = goenv.NewGoVersions(env)
+	flags := cmd.Flags()
+	if flags.Changed("mirror") {
+		if vs.Mirrors, err = flags.GetStringSlice("mirror"); err != nil {
+			return nil, err
+		}
+	}
+	if vs.Retries, err = flags.GetInt("retries"); err != nil {
+		return nil, err
+	}
+	if vs.RetryBackoff, err = flags.GetDuration("retry-backoff"); err != nil {
+		return nil, err
+	}
+	if vs.KeepArchives, err = flags.GetBool("keep-archives"); err != nil {
+		return nil, err
+	}
+	return vs, nil
+}
@@ -17,7 +17,6 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/moisespsena-go/error-wrap"
 	"github.com/moisespsena-go/goenv"
 	"github.com/spf13/cobra"
 )
@@ -34,12 +33,12 @@ Examples:
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		desc, _ := cmd.Flags().GetBool("rsort")
-		env, err := goenv.NewGoEnv(db, false)
+		env, err := goenv.NewGoEnv(db, false, envOptions...)
 		if err != nil {
-			return errwrap.Wrap(err, "New Env")
+			return fmt.Errorf("New Env: %w", err)
 		}
 		v := goenv.NewGoVersions(env)
-		items, err := v.Available(desc, args...)
+		items, err := v.AvailableContext(ctx, desc, args...)
 		if err != nil {
 			return err
 		}
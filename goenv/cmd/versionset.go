@@ -15,7 +15,7 @@
 package cmd
 
 import (
-	"github.com/moisespsena-go/error-wrap"
+	"fmt"
 	"github.com/moisespsena-go/goenv"
 	"github.com/spf13/cobra"
 )
@@ -23,18 +23,22 @@ import (
 var versionsSetCmd = &cobra.Command{
 	Use:   "set VERSION ENV_NAME...",
 	Short: "Set version to env",
-	Args:  cobra.MinimumNArgs(2),
+	Long: `Set version to env.
+
+VERSION is an installed version, "sys" to use the go command found on PATH,
+or "sys:pin" to use the current system GOROOT even if PATH changes.`,
+	Args: cobra.MinimumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		env, err := goenv.NewGoEnv(db, false)
+		env, err := goenv.NewGoEnv(db, false, envOptions...)
 		if err != nil {
-			return errwrap.Wrap(err, "New Env")
+			return fmt.Errorf("New Env: %w", err)
 		}
 		vs := goenv.NewGoVersions(env)
 		versionName, args := args[0], args[1:]
 		for _, envName := range args {
 			err = vs.Set(versionName, envName)
 			if err != nil {
-				return errwrap.Wrap(err, "Set version to %q", envName)
+				return fmt.Errorf("Set version to %q: %w", envName, err)
 			}
 		}
 		return nil
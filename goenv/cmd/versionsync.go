@@ -0,0 +1,113 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var versionsSyncCmd = &cobra.Command{
+	Use:   "sync [ENV_NAME]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "Install and set the GoLang version required by go.mod to enviroment",
+	Long: `Install and set the GoLang version required by go.mod to enviroment.
+The version is the toolchain directive of go.mod, or the release of go
+directive if not defined.
+
+ENV_NAME defaults to the active enviroment ($GOENVNAME). The go.mod is searched
+in the current directory and its parents, or in the PROJECT dir.
+
+Examples:
+  $ goenv versions sync
+  $ goenv versions sync e1 -p github.com/me/project
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return fmt.Errorf("New Env: %w", err)
+		}
+		envName := os.Getenv("GOENVNAME")
+		if len(args) == 1 {
+			envName = args[0]
+		}
+		if envName == "" {
+			return fmt.Errorf("No enviroment name informed.")
+		}
+		dir, err := projectDir(cmd, env, envName)
+		if err != nil {
+			return err
+		}
+		vs, err := newGoVersions(cmd, env)
+		if err != nil {
+			return err
+		}
+		name, err := vs.SyncGoMod(envName, dir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("GoLang version %q set to %q.\n", name, envName)
+		return nil
+	},
+}
+
+func init() {
+	versionsSyncCmd.Flags().StringP("project", "p", "",
+		"Project dir. If relative and not exists, it's relative to src dir of enviroment.")
+	versionsCmd.AddCommand(versionsSyncCmd)
+}
+
+// projectDir returns the --project flag value, resolved against the src dir of
+// enviroment, or the current directory.
+func projectDir(cmd *cobra.Command, env *goenv.GoEnv, envName string) (string, error) {
+	dir, _ := cmd.Flags().GetString("project")
+	if dir == "" {
+		return os.Getwd()
+	}
+	if filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	if ok, err := goenv.IsDir(dir); err != nil || ok {
+		return dir, err
+	}
+	pth, err := env.GetCheck(envName)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(pth, "src", dir), nil
+}
+
+// warnGoMod writes to stderr a warning if the toolchain of enviroment is older
+// than the required by go.mod of current directory.
+func warnGoMod(env *goenv.GoEnv, envName string) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	check, err := env.CheckGoMod(envName, dir)
+	if err != nil || check == nil || !check.Outdated {
+		return
+	}
+	bound := check.Bound
+	if bound == "" {
+		bound = "a broken toolchain"
+	}
+	fmt.Fprintf(os.Stderr, "WARNING: %q requires %s, but %q uses %s. Run 'goenv versions sync %s'.\n",
+		check.GoMod.Path, check.GoMod.Required(), envName, bound, envName)
+}
@@ -0,0 +1,96 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var versionsUpgradeCmd = &cobra.Command{
+	Use:   "upgrade [ENV_NAME...]",
+	Short: "Upgrade enviroments to newest patch release of its GoLang version",
+	Long: `Upgrade enviroments to newest patch release of its GoLang version.
+The newest version, installed or available for download, of the current minor
+line (as go1.21.x) is installed if missing and set to enviroment.
+
+Examples:
+  $ goenv versions upgrade e1 e2
+  $ goenv versions upgrade --all --dry-run
+  $ goenv versions upgrade --all --minor
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return fmt.Errorf("New Env: %w", err)
+		}
+		flags := cmd.Flags()
+		all, _ := flags.GetBool("all")
+		options := &goenv.UpgradeOptions{}
+		options.Minor, _ = flags.GetBool("minor")
+		options.Offline, _ = flags.GetBool("offline")
+		dryRun, _ := flags.GetBool("dry-run")
+
+		if all {
+			if args, err = env.Ls(); err != nil {
+				return err
+			}
+		} else if len(args) == 0 {
+			return fmt.Errorf("No enviroments names informed.")
+		}
+
+		vs, err := newGoVersions(cmd, env)
+		if err != nil {
+			return err
+		}
+		plans, err := vs.PlanUpgradeContext(ctx, options, args...)
+		if err != nil {
+			return err
+		}
+		if !dryRun {
+			if err = vs.UpgradeContext(ctx, plans...); err != nil {
+				return err
+			}
+		}
+
+		fmt.Println(pad("Name"), pad("Before"), pad("After"), "Status")
+		for _, plan := range plans {
+			after, status := plan.To, plan.Reason
+			switch {
+			case after == "":
+				after = plan.From
+			case dryRun && plan.Install:
+				status = "install and upgrade"
+			case dryRun:
+				status = "upgrade"
+			default:
+				status = "upgraded"
+			}
+			fmt.Println(pad(plan.Env), pad(plan.From), pad(after), status)
+		}
+		return nil
+	},
+}
+
+func init() {
+	flags := versionsUpgradeCmd.Flags()
+	flags.BoolP("all", "a", false, "Upgrade all enviroments on database. Ignore names passed on arguments.")
+	flags.Bool("minor", false, "Also upgrade to newer minor versions (as go1.21.x to go1.22.y).")
+	flags.Bool("offline", false, "Use only the installed versions.")
+	flags.BoolP("dry-run", "n", false, "Show the upgrade plan without changing anything.")
+	versionsCmd.AddCommand(versionsUpgradeCmd)
+}
@@ -0,0 +1,67 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/moisespsena-go/goenv"
+	"github.com/spf13/cobra"
+)
+
+var versionsUsageCmd = &cobra.Command{
+	Use:   "usage [VERSION]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "List enviroments with bound version, GOROOT and status",
+	Long: `List enviroments with bound version, GOROOT and status.
+Enviroments whose toolchain has been deleted are flagged as BROKEN.
+
+If VERSION is informed, lists only enviroments using it. The "sys" VERSION
+also matches "sys:pin".`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, err := goenv.NewGoEnv(db, true, envOptions...)
+		if err != nil {
+			return fmt.Errorf("New Env: %w", err)
+		}
+		infos, err := env.EnvsInfo()
+		if err != nil {
+			return err
+		}
+		fmt.Println(pad("Name"), pad("Version"), pad("GOROOT", 40), "Status")
+		for _, info := range infos {
+			if len(args) == 1 {
+				version := strings.ToLower(args[0])
+				if info.GoVersion != version && info.Binding.Version != version {
+					continue
+				}
+			}
+			status := "ok"
+			if info.Broken {
+				status = "BROKEN"
+			}
+			root := info.GoRoot
+			if root == "" {
+				root = "-"
+			}
+			fmt.Println(pad(info.Name), pad(info.GoVersion), pad(root, 40), status)
+		}
+		return nil
+	},
+}
+
+func init() {
+	versionsCmd.AddCommand(versionsUsageCmd)
+}
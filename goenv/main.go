@@ -18,6 +18,7 @@ import (
 	"encoding/base64"
 	"strings"
 
+	"github.com/moisespsena-go/goenv"
 	"github.com/moisespsena-go/goenv/goenv/cmd"
 )
 
@@ -37,6 +38,10 @@ func init() {
 		commit,
 		date,
 		strings.TrimSpace(goversion)
+
+	if version != "" {
+		goenv.ProgramVersion = version
+	}
 }
 
 func main() {
@@ -0,0 +1,168 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPromptWord(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	dir := t.TempDir()
+	for format, want := range map[string]string{
+		"[go:$GOENVNAME] ":     "[go:e1] ",
+		"(${GOENVNAME}) ":      "(e1) ",
+		"$GOENVNAME$GOENVNAME": "e1e1",
+		`\u "it's" $HOME `:     `\u "it's" $HOME `,
+		`"; touch injected; echo "$(touch injected)` + "`touch injected`": `"; touch injected; echo "$(touch injected)` + "`touch injected`",
+	} {
+		script := `GOENVNAME=e1; PS1='> '; PS1=` + promptWord(format) + `"$PS1"; printf %s "$PS1"`
+		cmd := exec.Command("sh", "-c", script)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%q: %v: %s", format, err, out)
+		}
+		if got := strings.TrimSuffix(string(out), "> "); got != want {
+			t.Errorf("prompt of %q = %q, want %q", format, got, want)
+		}
+	}
+	if ok, _ := IsFile(filepath.Join(dir, "injected")); ok {
+		t.Error("prompt format is executed")
+	}
+}
+
+// runActivated runs the bash script after sourcing the activate script of
+// enviroment dir pth, with the enviroment variables env.
+func runActivated(t *testing.T, goEnv *GoEnv, pth, script string, env ...string) string {
+	t.Helper()
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("requires bash")
+	}
+	// the activate script gets the database of goenv command
+	bin := t.TempDir()
+	writeTestFile(t, filepath.Join(bin, "goenv"), "#!/bin/sh\necho "+shellQuote(goEnv.DbDir)+"\n")
+	if err = os.Chmod(filepath.Join(bin, "goenv"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(bash, "-c", ". ./activate && "+script)
+	cmd.Dir = pth
+	cmd.Env = append(append(os.Environ(), "PATH="+bin+string(filepath.ListSeparator)+os.Getenv("PATH")), env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	return string(out)
+}
+
+func TestActivateScriptQuoting(t *testing.T) {
+	env := newTestEnv(t, "e1")
+	pth := filepath.Join(env.DbDir, "e1")
+	value := `it's "$(touch injected)" ` + "`touch injected`"
+	writeTestFile(t, filepath.Join(pth, SETTINGS_DIR, ENV_SETTINGS_NAME), "FOO="+value+"\n")
+	goRoot := filepath.Join(t.TempDir(), "go's root")
+	if err := env.CreateActivate(pth, goRoot); err != nil {
+		t.Fatal(err)
+	}
+
+	out := runActivated(t, env, pth, `printf '%s\n' "$FOO" "$GOROOT" "$GOENVNAME"`)
+	if want := value + "\n" + goRoot + "\ne1\n"; out != want {
+		t.Errorf("activate exports %q, want %q", out, want)
+	}
+	if ok, _ := IsFile(pth, "injected"); ok {
+		t.Error("variable value is executed")
+	}
+	if got, err := env.GoRoot("e1"); err != nil || got != goRoot {
+		t.Errorf("GoRoot = %q, %v, want %q", got, err, goRoot)
+	}
+}
+
+func TestDeactivate(t *testing.T) {
+	env := newTestEnv(t, "e1")
+	pth := filepath.Join(env.DbDir, "e1")
+	writeTestFile(t, filepath.Join(pth, SETTINGS_DIR, ENV_SETTINGS_NAME), "FOO=x\nBAR=y\n")
+	if err := env.CreateActivate(pth, "/opt/go"); err != nil {
+		t.Fatal(err)
+	}
+	const script = `printf '%s|' "$GOROOT" "${GOMODCACHE-unset}" "$FOO" "${BAR-unset}"; ` +
+		`goenv-deactivate; printf '%s|' "$GOROOT" "${GOMODCACHE-unset}" "${FOO-unset}" "${BAR-unset}" "${GOPATH-unset}" "${GOENVNAME-unset}" "$PATH"`
+	out := runActivated(t, env, pth, script, "GOROOT=/usr/lib/go", "GOMODCACHE=/cache", "FOO=old", "GOPATH=/home/go")
+	prefix := "/opt/go|unset|x|y|/usr/lib/go|/cache|old|unset|/home/go|unset|"
+	if !strings.HasPrefix(out, prefix) {
+		t.Fatalf("output %q, want prefix %q", out, prefix)
+	}
+	if path := strings.TrimSuffix(strings.TrimPrefix(out, prefix), "|"); strings.Contains(path, "/opt/go") || strings.Contains(path, pth) {
+		t.Errorf("PATH isn't restored: %q", path)
+	}
+
+	// the shared module cache is exported
+	if err := env.SetModCacheMode("e1", MODCACHE_SHARED); err != nil {
+		t.Fatal(err)
+	}
+	out = runActivated(t, env, pth, `printf '%s|' "$GOMODCACHE"; goenv-deactivate; printf '%s' "${GOMODCACHE-unset}"`, "GOMODCACHE=/cache")
+	if want := filepath.Join(env.DbDir, CACHE_BASENAME, "mod") + "|/cache"; out != want {
+		t.Errorf("output %q, want %q", out, want)
+	}
+}
+
+func TestShellUnquote(t *testing.T) {
+	for _, s := range []string{"", "a b", "it's", `"$GOENVROOT"`, `\'"'`} {
+		got, err := shellUnquote(shellQuote(s))
+		if err != nil || got != s {
+			t.Errorf("shellUnquote(shellQuote(%q)) = %q, %v", s, got, err)
+		}
+	}
+	if got, _ := shellUnquote(rootWord("$GOENVROOT/.goversions/go1.22")); got != `$GOENVROOT/.goversions/go1.22` {
+		t.Errorf("root word = %q", got)
+	}
+}
+
+func TestActivateOutdatedCacheMode(t *testing.T) {
+	t.Setenv(GetConfigKey("cache.mode").EnvName(), "")
+	env := newTestEnv(t, "e1", "e2")
+	if err := env.SetModCacheMode("e2", MODCACHE_ISOLATED); err != nil {
+		t.Fatal(err)
+	}
+	checkOutdated := func(name string, want bool) {
+		t.Helper()
+		if outdated, err := env.ActivateOutdated(name); err != nil {
+			t.Fatal(err)
+		} else if outdated != want {
+			t.Errorf("%s: outdated %v, want %v", name, outdated, want)
+		}
+	}
+	checkOutdated("e1", false)
+
+	// e1 uses the config mode, e2 has its own mode
+	env.Config.File("db").Set("cache.mode", MODCACHE_SHARED)
+	checkOutdated("e1", true)
+	checkOutdated("e2", false)
+
+	if _, err := env.Update("e1"); err != nil {
+		t.Fatal(err)
+	}
+	checkOutdated("e1", false)
+	if data := readTestFile(t, filepath.Join(env.DbDir, "e1", "activate")); !strings.Contains(data, "export GOMODCACHE=") {
+		t.Error("updated activate script doesn't export the shared module cache")
+	}
+}
@@ -15,23 +15,90 @@
 package goenv
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+
+	"github.com/dustin/go-humanize"
 )
 
 type GoEnvCmd struct {
 	Env *GoEnv
 }
 
-func NewGoEnvCmd(dbDir string, check bool) (envCmd *GoEnvCmd, err error) {
-	env, err := NewGoEnv(dbDir, check)
+// NewGoEnvCmd returns the GoEnvCmd with CmdLogger writing to STDOUT and
+// STDERR, unless opts overrides it.
+func NewGoEnvCmd(dbDir string, check bool, opts ...Option) (envCmd *GoEnvCmd, err error) {
+	opts = append([]Option{WithLogger(NewCmdLogger(os.Stdout, os.Stderr))}, opts...)
+	env, err := NewGoEnv(dbDir, check, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return &GoEnvCmd{env}, nil
 }
 
+// CmdLogger is the Logger of command line, that writes the events as text
+// lines. The failures are written to Stderr.
+type CmdLogger struct {
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func NewCmdLogger(stdout, stderr io.Writer) *CmdLogger {
+	return &CmdLogger{stdout, stderr}
+}
+
+func (l *CmdLogger) Event(e *Event) {
+	hb := func(value int64) string {
+		return humanize.Bytes(uint64(value)) + " (" + strconv.Itoa(int(value)) + " bytes)"
+	}
+	switch e.Type {
+	case EventAvailableFailed:
+		fmt.Fprintf(l.Stderr, "Get available versions failed: %v\nUsing mirrors only.\n", e.Err)
+	case EventDownloadStart:
+		fmt.Fprintf(l.Stdout, "[%v] Downloading %v...\n", e.Version, e.URL)
+	case EventDownloadResponse:
+		if e.Total >= 0 {
+			fmt.Fprintf(l.Stdout, "[%v] [HTTP %v] Left Size: %v\n", e.Version, e.Status, hb(e.Total))
+		} else {
+			fmt.Fprintf(l.Stdout, "[%v] [HTTP %v]\n", e.Version, e.Status)
+		}
+	case EventDownloadProgress:
+		var percent float64
+		if e.Total > 0 {
+			percent = 100 * float64(e.Bytes) / float64(e.Total)
+		}
+		fmt.Fprintf(l.Stdout, "[%v] transferred %v / %v (%.2f%%)\n", e.Version, hb(e.Bytes), hb(e.Total), percent)
+	case EventDownloadDone:
+		fmt.Fprintf(l.Stdout, "[%v] Download saved to %v\n", e.Version, e.Path)
+	case EventDownloadURLFailed:
+		fmt.Fprintf(l.Stderr, "[%v] Download from %v failed: %v\n", e.Version, e.URL, e.Err)
+	case EventDownloadRetry:
+		fmt.Fprintf(l.Stderr, "[%v] Retry %d of %d in %v...\n", e.Version, e.Attempt, e.Retries, e.Backoff)
+	case EventDownloadFailed:
+		fmt.Fprintf(l.Stderr, "[%v] Download failed: %v\n", e.Version, e.Err)
+	case EventExtractStart:
+		fmt.Fprintf(l.Stdout, "[%v] Extract %q to %q...\n", e.Version, e.Path, e.Target)
+	case EventExtractDone:
+		if e.Err == nil {
+			fmt.Fprintf(l.Stdout, "[%v] Extract done.\n", e.Version)
+		}
+	case EventExtractEntry:
+		size := ""
+		if e.Bytes >= 0 {
+			size = "[" + humanize.Bytes(uint64(e.Bytes)) + "]"
+		}
+		status := "done."
+		if e.Err != nil {
+			status = "failed."
+		}
+		fmt.Fprintf(l.Stdout, "%s %s %s... %s\n", pad(e.Kind, 5), pad(size, 12), e.Path, status)
+	}
+}
+
 func (cmd *GoEnvCmd) Setup() error {
 	os.Stdout.WriteString(`##############################
 ## - BEGIN GOENV COMMANDS - ##
@@ -66,14 +133,27 @@ func (cmd *GoEnvCmd) Ls() error {
 	if len(names) == 0 {
 		fmt.Fprintf(os.Stderr, "'%v': Database directory is empty.", cmd.Env.DbDir)
 	} else {
+		var outdated []string
 		for _, name := range names {
 			os.Stdout.WriteString(name + "\n")
+			if ok, err := cmd.Env.ActivateOutdated(name); err != nil {
+				return err
+			} else if ok {
+				outdated = append(outdated, name)
+			}
+		}
+		for _, name := range outdated {
+			fmt.Fprintf(os.Stderr, "WARNING: activate script of %q is outdated. Run 'goenv update %s'.\n", name, name)
 		}
 	}
 	return nil
 }
 
 func (cmd *GoEnvCmd) Init(names ...string) (err error) {
+	return cmd.InitWith(&InitOptions{}, names...)
+}
+
+func (cmd *GoEnvCmd) InitWith(options *InitOptions, names ...string) (err error) {
 	defer func() {
 		os.Stdout.Sync()
 		os.Stderr.Sync()
@@ -84,7 +164,7 @@ func (cmd *GoEnvCmd) Init(names ...string) (err error) {
 	for _, name := range names {
 		pth = filepath.Join(cmd.Env.DbDir, name)
 		fmt.Fprintf(os.Stdout, "Initializing virtual enviroment %q on %q...\n", name, pth)
-		err = cmd.Env.Init(name, "")
+		err = cmd.Env.InitWith(name, options)
 		if err != nil {
 			return
 		}
@@ -113,12 +193,8 @@ func (cmd *GoEnvCmd) Update(envs []string) error {
 		if err != nil {
 			return err
 		}
-		err = cmd.Env.Init(envName, "")
-		if err != nil {
-			return err
-		}
 	}
-	return nil
+	return cmd.update(envs)
 }
 
 func (cmd *GoEnvCmd) UpdateAll() error {
@@ -126,16 +202,40 @@ func (cmd *GoEnvCmd) UpdateAll() error {
 	if err != nil {
 		return err
 	}
+	return cmd.update(envs)
+}
+
+func (cmd *GoEnvCmd) update(envs []string) error {
+	var count int
 	for _, envName := range envs {
-		err = cmd.Env.Init(envName, "")
+		changed, err := cmd.Env.Update(envName)
 		if err != nil {
 			return err
 		}
+		if changed {
+			count++
+			fmt.Fprintf(os.Stdout, "Activate script of %q updated.\n", envName)
+		}
 	}
+	fmt.Fprintf(os.Stdout, "%d of %d activate scripts changed.\n", count, len(envs))
 	return nil
 }
 
 func (cmd *GoEnvCmd) ActivateCode(name string) error {
+	outdated, err := cmd.Env.ActivateOutdated(name)
+	if err != nil {
+		return err
+	}
+	if outdated {
+		if cmd.Env.AutoUpdate {
+			if _, err = cmd.Env.Update(name); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Activate script of %q updated.\n", name)
+		} else {
+			fmt.Fprintf(os.Stderr, "WARNING: activate script of %q is outdated. Run 'goenv update %s'.\n", name, name)
+		}
+	}
 	code, err := cmd.Env.ActivateCode(name)
 	if err != nil {
 		return err
@@ -159,3 +259,33 @@ func (cmd *GoEnvCmd) Rm(name string, delete bool) error {
 	os.Stdout.Sync()
 	return nil
 }
+
+func (env *GoEnvCmd) Backup(name string, options *BackupOptions) error {
+	return env.BackupContext(context.Background(), name, options)
+}
+
+func (env *GoEnvCmd) BackupContext(ctx context.Context, name string, options *BackupOptions) error {
+	pth, err := env.Env.BackupContext(ctx, name, options)
+	if err != nil {
+		return fmt.Errorf("Backup for %q failed: %w", name, err)
+	}
+	if pth != "" {
+		fmt.Fprintf(os.Stdout, "Backup save on %q\n", pth)
+	}
+	return nil
+}
+
+func (env *GoEnvCmd) Restore(options *RestoreOptions) error {
+	return env.RestoreContext(context.Background(), options)
+}
+
+func (env *GoEnvCmd) RestoreContext(ctx context.Context, options *RestoreOptions) error {
+	pth, err := env.Env.RestoreContext(ctx, options)
+	if err != nil {
+		return fmt.Errorf("Restore failed: %w", err)
+	}
+	if pth != "" {
+		fmt.Fprintf(os.Stdout, "Restore saved on %q\n", pth)
+	}
+	return nil
+}
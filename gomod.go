@@ -0,0 +1,227 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/moisespsena-go/error-wrap"
+	"golang.org/x/mod/modfile"
+)
+
+// GoModToolchain are the Go version directives of go.mod file.
+type GoModToolchain struct {
+	// Path is the go.mod file path.
+	Path string
+	// Go is the value of go directive, as "1.22".
+	Go string
+	// Toolchain is the value of toolchain directive, as "go1.22.3".
+	Toolchain string
+}
+
+// Required returns the toolchain version name required by go.mod. It's the
+// toolchain directive if defined, otherwise the release of go directive.
+func (m *GoModToolchain) Required() string {
+	if m.Toolchain != "" && m.Toolchain != "default" {
+		return m.Toolchain
+	}
+	if m.Go == "" {
+		return ""
+	}
+	name := "go" + m.Go
+	// since go1.21, "go 1.21" means the go1.21.0 release
+	if v, _ := parseGoVersion(name); isGoRelease(name) && v[2] == -1 && compareGoVersion(name, "go1.21") >= 0 {
+		name += ".0"
+	}
+	return name
+}
+
+// ReadGoModToolchain reads the Go version directives of go.mod file.
+func ReadGoModToolchain(pth string) (*GoModToolchain, error) {
+	data, err := ioutil.ReadFile(pth)
+	if err != nil {
+		return nil, errwrap.Wrap(err, "Read %q", pth)
+	}
+	f, err := modfile.Parse(pth, data, nil)
+	if err != nil {
+		return nil, errwrap.Wrap(err, "Parse %q", pth)
+	}
+	m := &GoModToolchain{Path: pth}
+	if f.Go != nil {
+		m.Go = f.Go.Version
+	}
+	if f.Toolchain != nil {
+		m.Toolchain = f.Toolchain.Name
+	}
+	return m, nil
+}
+
+// FindGoMod returns the go.mod file of dir or of its nearest parent, or empty
+// string if not found.
+func FindGoMod(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		pth := filepath.Join(dir, "go.mod")
+		if ok, err := IsFile(pth); err != nil {
+			return "", errwrap.Wrap(err, "Check %q", pth)
+		} else if ok {
+			return pth, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// GoModCheck is the result of CheckGoMod.
+type GoModCheck struct {
+	Env   string
+	GoMod *GoModToolchain
+	// Bound is the Go version of enviroment toolchain, or empty if broken.
+	Bound string
+	// Outdated is true if Bound is older than the required by go.mod.
+	Outdated bool
+}
+
+// CheckGoMod compares the toolchain of enviroment with the required by go.mod
+// of dir (or of its nearest parent). Returns nil if go.mod not found.
+func (env *GoEnv) CheckGoMod(envName, dir string) (check *GoModCheck, err error) {
+	pth, err := FindGoMod(dir)
+	if err != nil || pth == "" {
+		return nil, err
+	}
+	check = &GoModCheck{Env: envName}
+	if check.GoMod, err = ReadGoModToolchain(pth); err != nil {
+		return nil, err
+	}
+	info, err := env.EnvInfo(envName)
+	if err != nil {
+		return nil, err
+	}
+	if info.RootExists {
+		bin, err := NewGoBinVersion(filepath.Join(info.GoRoot, "bin", "go"))
+		if err != nil {
+			return nil, err
+		}
+		check.Bound = bin.Version
+	}
+	required := check.GoMod.Required()
+	check.Outdated = required != "" && (check.Bound == "" || compareGoVersion(check.Bound, required) < 0)
+	return
+}
+
+// SyncGoMod installs, if missing, the toolchain required by go.mod of dir
+// and binds it to enviroment. Returns the bound version name.
+func (vs *GoVersions) SyncGoMod(envName, dir string) (string, error) {
+	pth, err := FindGoMod(dir)
+	if err != nil {
+		return "", err
+	}
+	if pth == "" {
+		return "", fmt.Errorf("go.mod not found in %q.", dir)
+	}
+	m, err := ReadGoModToolchain(pth)
+	if err != nil {
+		return "", err
+	}
+	name := m.Required()
+	if name == "" {
+		return "", fmt.Errorf("%q doesn't requires a Go version.", pth)
+	}
+	version, err := vs.Get(name)
+	if err != nil {
+		return "", err
+	}
+	if version == nil {
+		versions, err := vs.Install(name[2:])
+		if err != nil {
+			return "", err
+		}
+		if len(versions) == 0 {
+			return "", fmt.Errorf("GoLang version %q isn't available.", name)
+		}
+	}
+	return name, vs.Set(name, envName)
+}
+
+var goVersionRe = regexp.MustCompile(`^go(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:(beta|rc)(\d+))?`)
+
+// parseGoVersion returns the major, minor, patch, pre-release kind (0 for
+// beta, 1 for rc, 2 for release) and pre-release number of version name.
+// Missing numbers are -1. If name has other suffix, as "go1.21.3-custom",
+// ok is false.
+func parseGoVersion(name string) (v [5]int, ok bool) {
+	v = [5]int{-1, -1, -1, 2, 0}
+	m := goVersionRe.FindStringSubmatch(name)
+	if m == nil {
+		return
+	}
+	for i, s := range m[1:4] {
+		if s != "" {
+			v[i], _ = strconv.Atoi(s)
+		}
+	}
+	switch m[4] {
+	case "beta":
+		v[3] = 0
+	case "rc":
+		v[3] = 1
+	}
+	if m[5] != "" {
+		v[4], _ = strconv.Atoi(m[5])
+	}
+	return v, len(m[0]) == len(name)
+}
+
+// isGoRelease reports whether name is a release version name, as "go1.21"
+// or "go1.21.4".
+func isGoRelease(name string) bool {
+	v, ok := parseGoVersion(name)
+	return ok && v[1] != -1 && v[3] == 2
+}
+
+// compareGoVersion compares the Go version names, as "go1.21", "go1.21.3" or
+// "go1.22rc1". The missing patch is equal to zero.
+func compareGoVersion(a, b string) int {
+	va, _ := parseGoVersion(a)
+	vb, _ := parseGoVersion(b)
+	for i := range va {
+		x, y := va[i], vb[i]
+		if i == 2 {
+			if x == -1 {
+				x = 0
+			}
+			if y == -1 {
+				y = 0
+			}
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
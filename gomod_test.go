@@ -0,0 +1,110 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReadGoModToolchain(t *testing.T) {
+	for _, test := range []struct {
+		data      string
+		goVersion string
+		toolchain string
+		required  string
+	}{
+		{"module m\n", "", "", ""},
+		{"module m\n\ngo 1.16\n", "1.16", "", "go1.16"},
+		{"module m\n\ngo 1.21\n", "1.21", "", "go1.21.0"},
+		{"module m\n\ngo 1.22.3\n", "1.22.3", "", "go1.22.3"},
+		{"module m\n\ngo 1.22rc1\n", "1.22rc1", "", "go1.22rc1"},
+		{"module m\n\ngo 1.21\n\ntoolchain go1.22.1\n", "1.21", "go1.22.1", "go1.22.1"},
+		{"module m\n\ngo 1.21\n\ntoolchain default\n", "1.21", "default", "go1.21.0"},
+	} {
+		pth := filepath.Join(t.TempDir(), "go.mod")
+		writeTestFile(t, pth, test.data)
+		m, err := ReadGoModToolchain(pth)
+		if err != nil {
+			t.Fatalf("%q: %v", test.data, err)
+		}
+		if m.Path != pth || m.Go != test.goVersion || m.Toolchain != test.toolchain {
+			t.Errorf("%q: read %+v", test.data, *m)
+		}
+		if required := m.Required(); required != test.required {
+			t.Errorf("%q: requires %q, want %q", test.data, required, test.required)
+		}
+	}
+
+	pth := filepath.Join(t.TempDir(), "go.mod")
+	writeTestFile(t, pth, "module m\n\ngo x.y\n")
+	if _, err := ReadGoModToolchain(pth); err == nil {
+		t.Error("invalid go.mod is read")
+	}
+	if _, err := ReadGoModToolchain(filepath.Join(t.TempDir(), "go.mod")); err == nil {
+		t.Error("missing go.mod is read")
+	}
+}
+
+func TestCompareGoVersion(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		want int
+	}{
+		{"go1.21.3", "go1.21.3", 0},
+		{"go1.21", "go1.21.0", 0},
+		{"go1.21.3", "go1.21.10", -1},
+		{"go1.9", "go1.10", -1},
+		{"go1.22.0", "go1.21.9", 1},
+		{"go2", "go1.99", 1},
+		{"go1.22beta1", "go1.22rc1", -1},
+		{"go1.22rc1", "go1.22rc2", -1},
+		{"go1.22rc2", "go1.22", -1},
+		{"go1.22rc2", "go1.22.0", -1},
+		{"go1.21.9", "go1.22beta1", -1},
+	} {
+		if got := compareGoVersion(test.a, test.b); got != test.want {
+			t.Errorf("compare %s with %s: %d, want %d", test.a, test.b, got, test.want)
+		}
+		if got := compareGoVersion(test.b, test.a); got != -test.want {
+			t.Errorf("compare %s with %s: %d, want %d", test.b, test.a, got, -test.want)
+		}
+	}
+}
+
+func TestParseGoVersion(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		v       [5]int
+		ok      bool
+		release bool
+	}{
+		{"go1.21.3", [5]int{1, 21, 3, 2, 0}, true, true},
+		{"go1.21", [5]int{1, 21, -1, 2, 0}, true, true},
+		{"go1.22rc1", [5]int{1, 22, -1, 1, 1}, true, false},
+		{"go1.22beta2", [5]int{1, 22, -1, 0, 2}, true, false},
+		{"go1.21.3-custom", [5]int{1, 21, 3, 2, 0}, false, false},
+		{"go2", [5]int{2, -1, -1, 2, 0}, true, false},
+		{"1.21", [5]int{-1, -1, -1, 2, 0}, false, false},
+	} {
+		v, ok := parseGoVersion(test.name)
+		if v != test.v || ok != test.ok {
+			t.Errorf("%s: parsed %v %v, want %v %v", test.name, v, ok, test.v, test.ok)
+		}
+		if release := isGoRelease(test.name); release != test.release {
+			t.Errorf("%s: release %v, want %v", test.name, release, test.release)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestEnv returns the GoEnv of new database with the enviroments names.
+// Each enviroment has the file src/NAME/main.go.
+func newTestEnv(t *testing.T, names ...string) *GoEnv {
+	t.Helper()
+	env, err := NewGoEnv(filepath.Join(t.TempDir(), "db"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = env.InitDB(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if err = env.Init(name, ""); err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, filepath.Join(env.DbDir, name, "src", name, "main.go"), "package main // "+name+"\n")
+	}
+	return env
+}
+
+func writeTestFile(t *testing.T, pth, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(pth, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(t *testing.T, pth string) string {
+	t.Helper()
+	data, err := ioutil.ReadFile(pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
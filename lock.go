@@ -0,0 +1,328 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moisespsena-go/error-wrap"
+)
+
+// LOCKS_BASENAME is the database dir of lock files.
+const LOCKS_BASENAME = ".locks"
+
+// Lock scopes. The enviroment scopes are "env.NAME".
+const (
+	LOCK_DB       = "db"
+	LOCK_VERSIONS = "goversions"
+	LOCK_TRASH    = "trash"
+	LOCK_CACHE    = "cache"
+)
+
+// LockPollInterval is the interval between lock attempts.
+var LockPollInterval = 50 * time.Millisecond
+
+// LOCKS_INHERITED_ENV is the enviroment variable, set on commands run by
+// GoEnv, with the database dir whose locks are held by the parent process.
+// The child process doesn't lock the scopes of LOCKS_INHERITED_SCOPES_ENV,
+// because the parent waits it.
+const LOCKS_INHERITED_ENV = "GOENV_LOCKS_INHERITED"
+
+// LOCKS_INHERITED_SCOPES_ENV is the enviroment variable with the scopes held
+// by the parent process, one per line, as "shared:SCOPE" or
+// "exclusive:SCOPE".
+const LOCKS_INHERITED_SCOPES_ENV = "GOENV_LOCKS_INHERITED_SCOPES"
+
+// lockSet holds the locks of one locked call chain. The locking operations
+// run on a copy of GoEnv, the locked view, sharing the lockSet with the
+// nested operations, so the locks are reentrant along the call chain only.
+// Each lockSet opens its own lock files, so the other call chains wait it,
+// even if they share the GoEnv.
+type lockSet struct {
+	mu   sync.Mutex
+	held map[string]*heldLock
+}
+
+type heldLock struct {
+	f      *os.File
+	shared bool
+	count  int
+	// ready is closed when the lock is acquired or fails. It's nil after.
+	ready chan struct{}
+}
+
+// LockTimeoutError is returned when the lock isn't acquired before timeout.
+type LockTimeoutError struct {
+	Scope   string
+	Timeout time.Duration
+}
+
+func (e *LockTimeoutError) Error() string {
+	return fmt.Sprintf("Lock %q is held by other process (waited %s).", e.Scope, e.Timeout)
+}
+
+func (e *LockTimeoutError) Unwrap() error {
+	return ErrLockTimeout
+}
+
+func envLockScope(name string) string {
+	return "env." + strings.NewReplacer("/", "_", `\`, "_").Replace(name)
+}
+
+// lockView returns env if it is the view of a call chain holding locks, else
+// a new view with empty lockSet.
+func (env *GoEnv) lockView() *GoEnv {
+	if ls := env.locks; ls != nil {
+		ls.mu.Lock()
+		active := len(ls.held) > 0
+		ls.mu.Unlock()
+		if active {
+			return env
+		}
+	}
+	view := *env
+	view.locks = &lockSet{}
+	return &view
+}
+
+// lock acquires the advisory lock of scope and returns the locked view of env,
+// whose operations reenter the lock. If shared is true, other shared locks of
+// scope are allowed. The lock is waited up to LockTimeout: zero fails if held
+// and negative waits forever. The shared lock held by the call chain can't be
+// upgraded to exclusive: the exclusive lock must be acquired first.
+func (env *GoEnv) lock(scope string, shared bool) (locked *GoEnv, unlock func(), err error) {
+	locked = env.lockView()
+	ls := locked.locks
+	ls.mu.Lock()
+	if ls.held == nil {
+		ls.held = map[string]*heldLock{}
+	}
+	h := ls.held[scope]
+	for h != nil && h.ready != nil {
+		// acquiring by other goroutine of call chain
+		ready := h.ready
+		ls.mu.Unlock()
+		<-ready
+		ls.mu.Lock()
+		h = ls.held[scope]
+	}
+
+	if h == nil {
+		if inherited, ok := env.inheritedLocks()[scope]; ok {
+			h = &heldLock{shared: inherited}
+			ls.held[scope] = h
+		}
+	}
+	if h != nil {
+		defer ls.mu.Unlock()
+		if h.shared && !shared {
+			return nil, nil, fmt.Errorf("Lock %q is held shared and can't be upgraded to exclusive.", scope)
+		}
+		h.count++
+		return locked, ls.unlocker(scope, h), nil
+	}
+
+	// waits without the mutex, so the other scopes aren't blocked
+	h = &heldLock{shared: shared, ready: make(chan struct{})}
+	ls.held[scope] = h
+	ls.mu.Unlock()
+
+	f, err := env.acquireLock(scope, shared)
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	close(h.ready)
+	h.ready = nil
+	if err != nil {
+		delete(ls.held, scope)
+		return nil, nil, err
+	}
+	h.f = f
+	h.count++
+	return locked, ls.unlocker(scope, h), nil
+}
+
+func (ls *lockSet) unlocker(scope string, h *heldLock) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ls.mu.Lock()
+			defer ls.mu.Unlock()
+			if h.count--; h.count == 0 {
+				if h.f != nil {
+					unlockFile(h.f)
+					h.f.Close()
+				}
+				delete(ls.held, scope)
+			}
+		})
+	}
+}
+
+// acquireLock opens the lock file of scope and waits the lock.
+func (env *GoEnv) acquireLock(scope string, shared bool) (*os.File, error) {
+	if ok, err := IsDir(env.DbDir); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, &DBError{env.DbDir, ErrDBNotInitialized}
+	}
+	dir := filepath.Join(env.DbDir, LOCKS_BASENAME)
+	if err := MkdirAll(dir); err != nil {
+		return nil, err
+	}
+	pth := filepath.Join(dir, scope+".lock")
+	f, err := os.OpenFile(pth, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, errwrap.Wrap(err, "Open lock file %q", pth)
+	}
+	if err = env.waitLock(f, scope, shared); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// inheritedLocks returns the shared flag by scope of locks held by the parent
+// process.
+func (env *GoEnv) inheritedLocks() (scopes map[string]bool) {
+	inherited := os.Getenv(LOCKS_INHERITED_ENV)
+	if inherited == "" {
+		return nil
+	}
+	if dbDir, err := filepath.Abs(env.DbDir); err != nil || dbDir != inherited {
+		return nil
+	}
+	scopes = map[string]bool{}
+	for _, line := range strings.Split(os.Getenv(LOCKS_INHERITED_SCOPES_ENV), "\n") {
+		if parts := strings.SplitN(line, ":", 2); len(parts) == 2 {
+			scopes[parts[1]] = parts[0] != "exclusive"
+		}
+	}
+	return
+}
+
+// lockedEnv returns the enviroment variables of child process inheriting the
+// locks held by call chain of env.
+func (env *GoEnv) lockedEnv() ([]string, error) {
+	dbDir, err := filepath.Abs(env.DbDir)
+	if err != nil {
+		return nil, err
+	}
+	var scopes []string
+	if ls := env.locks; ls != nil {
+		ls.mu.Lock()
+		defer ls.mu.Unlock()
+		for scope, h := range ls.held {
+			if h.ready != nil {
+				continue
+			}
+			if h.shared {
+				scopes = append(scopes, "shared:"+scope)
+			} else {
+				scopes = append(scopes, "exclusive:"+scope)
+			}
+		}
+	}
+	sort.Strings(scopes)
+	return []string{LOCKS_INHERITED_ENV + "=" + dbDir, LOCKS_INHERITED_SCOPES_ENV + "=" + strings.Join(scopes, "\n")}, nil
+}
+
+func (env *GoEnv) waitLock(f *os.File, scope string, shared bool) error {
+	start := time.Now()
+	for {
+		ok, err := tryLockFile(f, shared)
+		if err != nil {
+			return errwrap.Wrap(err, "Lock %q", scope)
+		}
+		if ok {
+			return nil
+		}
+		if env.LockTimeout >= 0 && time.Since(start) >= env.LockTimeout {
+			return &LockTimeoutError{scope, env.LockTimeout}
+		}
+		time.Sleep(LockPollInterval)
+	}
+}
+
+// LockDB locks the database and returns the locked view of env, whose
+// operations reenter the held locks. The other operations, including the ones
+// called on env, wait the locks. The enviroment locks also acquires the
+// shared database lock, so the exclusive lock waits all enviroment operations.
+func (env *GoEnv) LockDB(shared bool) (locked *GoEnv, unlock func(), err error) {
+	return env.lock(LOCK_DB, shared)
+}
+
+// LockEnv locks the enviroments, after the shared database lock, and returns
+// the locked view of env (see LockDB). If shared is true, only the mutations
+// are blocked.
+func (env *GoEnv) LockEnv(shared bool, names ...string) (locked *GoEnv, unlock func(), err error) {
+	var unlocks []func()
+	unlock = func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+	locked, u, err := env.LockDB(true)
+	if err != nil {
+		return nil, nil, err
+	}
+	unlocks = append(unlocks, u)
+
+	// sorted to prevent deadlocks
+	names = append([]string{}, names...)
+	sort.Strings(names)
+	for i, name := range names {
+		if i > 0 && names[i-1] == name {
+			continue
+		}
+		if _, u, err = locked.lock(envLockScope(name), shared); err != nil {
+			unlock()
+			return nil, nil, fmt.Errorf("Lock enviroment %q: %w", name, err)
+		}
+		unlocks = append(unlocks, u)
+	}
+	return
+}
+
+// Lock locks the versions dir, after the shared database lock, and returns
+// the locked view of vs, whose Env is the locked view (see GoEnv.LockDB).
+func (vs *GoVersions) Lock(shared bool) (locked *GoVersions, unlock func(), err error) {
+	env, unlockDB, err := vs.Env.LockDB(true)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, u, err := env.lock(LOCK_VERSIONS, shared)
+	if err != nil {
+		unlockDB()
+		return nil, nil, err
+	}
+	return vs.withEnv(env), func() {
+		u()
+		unlockDB()
+	}, nil
+}
+
+// withEnv returns the copy of vs with the enviroment env.
+func (vs *GoVersions) withEnv(env *GoEnv) *GoVersions {
+	view := *vs
+	view.Env = env
+	return &view
+}
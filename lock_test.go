@@ -0,0 +1,328 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const lockHelperEnv = "GOENV_TEST_LOCK_HELPER"
+
+func TestLockGoroutines(t *testing.T) {
+	env := newTestEnv(t, "e1")
+	var (
+		wg     sync.WaitGroup
+		inside int32
+		count  int32
+	)
+	// the goroutines share the GoEnv
+	env.LockTimeout = -1
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				_, unlock, err := env.LockEnv(false, "e1")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if atomic.AddInt32(&inside, 1) != 1 {
+					t.Error("exclusive lock is held by two goroutines")
+				}
+				atomic.AddInt32(&count, 1)
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&inside, -1)
+				unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if count != 40 {
+		t.Errorf("count = %d, want 40", count)
+	}
+}
+
+func TestLockWaitDoesntBlockOtherScopes(t *testing.T) {
+	env := newTestEnv(t, "e1", "e2")
+	other, err := NewGoEnv(env.DbDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, unlockOther, err := other.LockEnv(false, "e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env.LockTimeout = -1
+	env, unlock, err := env.LockDB(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+	acquired := make(chan func())
+	go func() {
+		_, unlock, err := env.LockEnv(false, "e1")
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- unlock
+	}()
+	time.Sleep(5 * LockPollInterval)
+
+	// the same call chain locks other scopes while waiting
+	done := make(chan error)
+	go func() {
+		_, unlock, err := env.LockEnv(false, "e2")
+		if err == nil {
+			unlock()
+		}
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock of e2 is blocked by the wait of e1")
+	}
+
+	// the other goroutines of call chain waits the same scope
+	go func() {
+		_, unlock, err := env.LockEnv(true, "e1")
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- unlock
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("lock of e1 is acquired while held by other process")
+	case <-time.After(5 * LockPollInterval):
+	}
+	unlockOther()
+	for i := 0; i < 2; i++ {
+		select {
+		case unlock := <-acquired:
+			defer unlock()
+		case <-time.After(5 * time.Second):
+			t.Fatal("lock of e1 isn't acquired after release")
+		}
+	}
+}
+
+func TestLockCallChain(t *testing.T) {
+	env := newTestEnv(t, "e1")
+	env.LockTimeout = 0
+	locked, unlock, err := env.LockEnv(false, "e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the other goroutines sharing the GoEnv wait the lock
+	done := make(chan error)
+	go func() {
+		_, err := env.Rm("e1", true)
+		done <- err
+	}()
+	if err = <-done; !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("Rm of other goroutine: %v, want ErrLockTimeout", err)
+	}
+	if _, err = env.GetCheck("e1"); err != nil {
+		t.Fatalf("e1 is removed while locked: %v", err)
+	}
+
+	// the operations of locked view reenter the lock
+	if _, err = locked.Update("e1"); err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if _, err = env.Rm("e1", true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockUpgrade(t *testing.T) {
+	env := newTestEnv(t)
+	env.LockTimeout = -1
+	env, unlock, err := env.LockDB(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	done := make(chan error)
+	go func() {
+		_, unlock, err := env.LockDB(false)
+		if err == nil {
+			unlock()
+		}
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		if err == nil {
+			t.Fatal("shared lock is upgraded to exclusive")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock upgrade deadlocks")
+	}
+
+	// the shared lock is still held
+	unlockEx, err := newTestEnvLock(t, env.DbDir, false)
+	if err == nil {
+		unlockEx()
+		t.Fatal("exclusive lock of other GoEnv is acquired while shared is held")
+	}
+}
+
+func newTestEnvLock(t *testing.T, dbDir string, shared bool) (func(), error) {
+	t.Helper()
+	env, err := NewGoEnv(dbDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.LockTimeout = 0
+	_, unlock, err := env.LockDB(shared)
+	return unlock, err
+}
+
+func TestLockExclusiveReentrant(t *testing.T) {
+	env := newTestEnv(t, "e1")
+	env, unlock, err := env.LockDB(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+	_, unlockEnv, err := env.LockEnv(true, "e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlockEnv()
+	if _, err = newTestEnvLock(t, env.DbDir, true); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("lock of other GoEnv: %v, want ErrLockTimeout", err)
+	}
+}
+
+func TestLockNotInitialized(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "db")
+	env, err := NewGoEnv(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = env.LockDB(true); !errors.Is(err, ErrDBNotInitialized) {
+		t.Errorf("lock of missing database: %v, want ErrDBNotInitialized", err)
+	}
+	if ok, _ := IsDir(dir); ok {
+		t.Error("database is created by lock")
+	}
+}
+
+func TestLockInherited(t *testing.T) {
+	env := newTestEnv(t, "e1", "e2")
+	parent, err := NewGoEnv(env.DbDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, unlockParent, err := parent.LockEnv(false, "e1", "e2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlockParent()
+
+	dbDir, _ := filepath.Abs(env.DbDir)
+	t.Setenv(LOCKS_INHERITED_ENV, dbDir)
+	t.Setenv(LOCKS_INHERITED_SCOPES_ENV, "shared:"+LOCK_DB+"\nexclusive:"+envLockScope("e1"))
+	env.LockTimeout = 0
+
+	_, unlock, err := env.LockEnv(false, "e1")
+	if err != nil {
+		t.Fatalf("inherited lock: %v", err)
+	}
+	unlock()
+	if _, _, err = env.LockEnv(false, "e2"); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("lock not held by parent: %v, want ErrLockTimeout", err)
+	}
+	if _, _, err = env.LockDB(false); err == nil {
+		t.Error("shared lock of parent is upgraded to exclusive")
+	}
+}
+
+// TestLockProcessHelper runs as child process of TestLockProcesses.
+func TestLockProcessHelper(t *testing.T) {
+	args := strings.Split(os.Getenv(lockHelperEnv), "\n")
+	if len(args) != 2 {
+		t.Skip("child process of TestLockProcesses")
+	}
+	env, err := NewGoEnv(args[0], true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.LockTimeout = -1
+	_, unlock, err := env.LockEnv(false, "e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+	f, err := os.OpenFile(args[1], os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "start %d\n", os.Getpid())
+	time.Sleep(50 * time.Millisecond)
+	fmt.Fprintf(f, "end %d\n", os.Getpid())
+}
+
+func TestLockProcesses(t *testing.T) {
+	env := newTestEnv(t, "e1")
+	log := filepath.Join(t.TempDir(), "log")
+	writeTestFile(t, log, "")
+
+	var cmds []*exec.Cmd
+	for i := 0; i < 4; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestLockProcessHelper$")
+		cmd.Env = append(os.Environ(), lockHelperEnv+"="+env.DbDir+"\n"+log)
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		cmds = append(cmds, cmd)
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := strings.Fields(strings.Replace(readTestFile(t, log), "\n", " ", -1))
+	if len(lines) != 16 {
+		t.Fatalf("log %q, want 8 lines", lines)
+	}
+	for i := 0; i < len(lines); i += 4 {
+		if lines[i] != "start" || lines[i+2] != "end" || lines[i+1] != lines[i+3] {
+			t.Fatalf("processes ran concurrently: %q", lines)
+		}
+	}
+}
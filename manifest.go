@@ -0,0 +1,117 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/moisespsena-go/error-wrap"
+)
+
+// MANIFEST_NAME is the name of the first entry of the backup archives.
+const MANIFEST_NAME = ".goenv-manifest.json"
+
+// ProgramVersion is the goenv version recorded into backup manifests.
+var ProgramVersion = "dev"
+
+// BackupManifest describes the contents of a backup archive.
+type BackupManifest struct {
+	GoEnvVersion string            `json:"goenv_version"`
+	Name         string            `json:"name"`
+	GoVersion    string            `json:"go_version,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	Exclude      []string          `json:"exclude,omitempty"`
+	Size         int64             `json:"size"`
+	Files        map[string]string `json:"files"`
+}
+
+func NewBackupManifest(name string) *BackupManifest {
+	return &BackupManifest{
+		GoEnvVersion: ProgramVersion,
+		Name:         name,
+		CreatedAt:    time.Now(),
+		Files:        map[string]string{},
+	}
+}
+
+// AddFile computes the SHA-256 hash of file pth and registers it as name.
+func (m *BackupManifest) AddFile(name, pth string) error {
+	f, err := os.Open(pth)
+	if err != nil {
+		return errwrap.Wrap(err, "Open %q", pth)
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return errwrap.Wrap(err, "Hash %q", pth)
+	}
+	m.Size += size
+	m.Files[filepath.ToSlash(name)] = hashString(h)
+	return nil
+}
+
+// checkFile checks the hash h of file contents with the hash registered as
+// name.
+func (m *BackupManifest) checkFile(name string, h hash.Hash) error {
+	expected, ok := m.Files[filepath.ToSlash(name)]
+	if !ok {
+		return fmt.Errorf("File %q isn't in manifest.", name)
+	}
+	if got := hashString(h); got != expected {
+		return fmt.Errorf("File %q hash %s doesn't match manifest hash %s.", name, got, expected)
+	}
+	return nil
+}
+
+func hashString(h hash.Hash) string {
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+func (m *BackupManifest) writeTo(tarWriter *tar.Writer) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errwrap.Wrap(err, "Encode manifest")
+	}
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     MANIFEST_NAME,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  m.CreatedAt,
+	}
+	if err = tarWriter.WriteHeader(header); err != nil {
+		return errwrap.Wrap(err, "Write manifest header")
+	}
+	_, err = tarWriter.Write(data)
+	return errwrap.Wrap(err, "Write manifest")
+}
+
+func readBackupManifest(reader io.Reader) (m *BackupManifest, err error) {
+	m = &BackupManifest{}
+	if err = json.NewDecoder(reader).Decode(m); err != nil {
+		return nil, errwrap.Wrap(err, "Decode manifest")
+	}
+	return
+}
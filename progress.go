@@ -0,0 +1,222 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Progress is the state of backup or restore operation. The totals are zero
+// if unknown.
+type Progress struct {
+	Op         string
+	Path       string
+	Bytes      int64
+	TotalBytes int64
+	Files      int
+	TotalFiles int
+	Started    time.Time
+	Done       bool
+}
+
+// Rate returns the throughput in bytes per second.
+func (p *Progress) Rate() float64 {
+	elapsed := time.Since(p.Started).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Bytes) / elapsed
+}
+
+// ETA returns the estimated time left, or -1 if unknown.
+func (p *Progress) ETA() time.Duration {
+	rate := p.Rate()
+	if p.TotalBytes == 0 || rate == 0 {
+		return -1
+	}
+	left := p.TotalBytes - p.Bytes
+	if left < 0 {
+		left = 0
+	}
+	return time.Duration(float64(left) / rate * float64(time.Second))
+}
+
+// Percent returns the done percentage of bytes, or -1 if unknown.
+func (p *Progress) Percent() float64 {
+	if p.TotalBytes == 0 {
+		return -1
+	}
+	return 100 * float64(p.Bytes) / float64(p.TotalBytes)
+}
+
+// ProgressFunc receives the progress updates. It's called at most
+// ProgressInterval times per second, and ever when done.
+type ProgressFunc func(p *Progress)
+
+// ProgressInterval is the minimum interval between ProgressFunc calls.
+var ProgressInterval = 100 * time.Millisecond
+
+type progressTracker struct {
+	Progress
+	cb   ProgressFunc
+	last time.Time
+}
+
+func newProgressTracker(op string, cb ProgressFunc) *progressTracker {
+	if cb == nil {
+		return nil
+	}
+	return &progressTracker{Progress: Progress{Op: op, Started: time.Now()}, cb: cb}
+}
+
+func (t *progressTracker) notify(force bool) {
+	if now := time.Now(); force || now.Sub(t.last) >= ProgressInterval {
+		t.last = now
+		p := t.Progress
+		t.cb(&p)
+	}
+}
+
+func (t *progressTracker) setTotal(bytes int64, files int) {
+	if t == nil {
+		return
+	}
+	t.TotalBytes, t.TotalFiles = bytes, files
+	t.notify(true)
+}
+
+func (t *progressTracker) file(pth string) {
+	if t == nil {
+		return
+	}
+	t.Path = pth
+	t.Files++
+	t.notify(false)
+}
+
+func (t *progressTracker) Write(p []byte) (int, error) {
+	t.Bytes += int64(len(p))
+	t.notify(false)
+	return len(p), nil
+}
+
+// writer returns w that also counts written bytes.
+func (t *progressTracker) writer(w io.Writer) io.Writer {
+	if t == nil {
+		return w
+	}
+	return io.MultiWriter(w, t)
+}
+
+func (t *progressTracker) done() {
+	if t == nil {
+		return
+	}
+	t.Done = true
+	t.notify(true)
+}
+
+// scanSize returns the size and count of regular files of tree.
+func scanSize(source string, exclude ValidFunc) (size int64, files int, err error) {
+	err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if exclude != nil && exclude(path, info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+			files++
+		}
+		return nil
+	})
+	return
+}
+
+// NewProgressReporter returns a ProgressFunc that writes the progress to w.
+// If tty is true, draws a progress bar, otherwise writes a log line every
+// interval.
+func NewProgressReporter(w io.Writer, tty bool, interval time.Duration) ProgressFunc {
+	var (
+		mu   sync.Mutex
+		last time.Time
+	)
+	return func(p *Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+		if tty {
+			line := "\r" + progressBar(p, 30) + " " + progressStatus(p) + "\033[K"
+			if p.Done {
+				line += "\n"
+			}
+			io.WriteString(w, line)
+			return
+		}
+		if now := time.Now(); p.Done || now.Sub(last) >= interval {
+			last = now
+			fmt.Fprintf(w, "%s: %s\n", p.Op, progressStatus(p))
+		}
+	}
+}
+
+func progressBar(p *Progress, width int) string {
+	percent := p.Percent()
+	if percent < 0 {
+		return "[" + strings.Repeat("?", width) + "]"
+	}
+	done := int(percent * float64(width) / 100)
+	if done > width {
+		done = width
+	}
+	bar := strings.Repeat("=", done)
+	if done < width {
+		bar += ">" + strings.Repeat(" ", width-done-1)
+	}
+	return "[" + bar + "]"
+}
+
+func progressStatus(p *Progress) string {
+	var parts []string
+	if percent := p.Percent(); percent >= 0 {
+		parts = append(parts, fmt.Sprintf("%3.0f%%", percent))
+		parts = append(parts, humanize.Bytes(uint64(p.Bytes))+"/"+humanize.Bytes(uint64(p.TotalBytes)))
+	} else {
+		parts = append(parts, humanize.Bytes(uint64(p.Bytes)))
+	}
+	if p.TotalFiles > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d files", p.Files, p.TotalFiles))
+	} else {
+		parts = append(parts, fmt.Sprintf("%d files", p.Files))
+	}
+	parts = append(parts, humanize.Bytes(uint64(p.Rate()))+"/s")
+	if p.Done {
+		parts = append(parts, "in "+time.Since(p.Started).Round(time.Second).String())
+	} else if eta := p.ETA(); eta >= 0 {
+		parts = append(parts, "ETA "+eta.Round(time.Second).String())
+	}
+	return strings.Join(parts, ", ")
+}
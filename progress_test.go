@@ -0,0 +1,164 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordProgress returns the ProgressFunc which records all updates.
+func recordProgress(t *testing.T) (*[]Progress, ProgressFunc) {
+	t.Helper()
+	interval := ProgressInterval
+	ProgressInterval = 0
+	t.Cleanup(func() { ProgressInterval = interval })
+	var updates []Progress
+	return &updates, func(p *Progress) {
+		updates = append(updates, *p)
+	}
+}
+
+// checkProgress checks the updates are increasing and done with the totals.
+func checkProgress(t *testing.T, op string, updates []Progress, size int64, files int) {
+	t.Helper()
+	if len(updates) < 2 {
+		t.Fatalf("%s: %d updates", op, len(updates))
+	}
+	for i, p := range updates {
+		if p.Op != op {
+			t.Errorf("%s: update %d has Op %q", op, i, p.Op)
+		}
+		if p.TotalBytes != size || p.TotalFiles != files {
+			t.Errorf("%s: update %d totals %d bytes, %d files, want %d, %d", op, i, p.TotalBytes, p.TotalFiles, size, files)
+		}
+		if i > 0 && (p.Bytes < updates[i-1].Bytes || p.Files < updates[i-1].Files) {
+			t.Errorf("%s: update %d decreases", op, i)
+		}
+		if p.Done != (i == len(updates)-1) {
+			t.Errorf("%s: update %d has Done %v", op, i, p.Done)
+		}
+	}
+	last := updates[len(updates)-1]
+	if last.Bytes != size || last.Files != files {
+		t.Errorf("%s: done with %d bytes, %d files, want %d, %d", op, last.Bytes, last.Files, size, files)
+	}
+}
+
+func TestProgressBackupRestore(t *testing.T) {
+	env := newLargeTestEnv(t)
+	size, files, err := scanSize(filepath.Join(env.DbDir, "e1"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files < 20 {
+		t.Fatalf("scan of %d files, want at least 20", files)
+	}
+
+	updates, progress := recordProgress(t)
+	target := filepath.Join(t.TempDir(), "e1.tar.gz")
+	if _, err = env.Backup("e1", &BackupOptions{Target: target, Progress: progress}); err != nil {
+		t.Fatal(err)
+	}
+	checkProgress(t, "backup", *updates, size, files)
+
+	*updates = nil
+	if _, err = env.Restore(&RestoreOptions{Source: target, Name: "e2", Progress: progress}); err != nil {
+		t.Fatal(err)
+	}
+	checkProgress(t, "restore", *updates, size, files)
+}
+
+func TestProgressScanExclude(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	writeTestFile(t, filepath.Join(src, "a.txt"), "aaaa")
+	writeTestFile(t, filepath.Join(src, "sub", "b.txt"), "bb")
+	writeTestFile(t, filepath.Join(src, "skip", "c.txt"), "cccccccc")
+	writeTestFile(t, filepath.Join(src, "d.log"), "dd")
+	exclude := func(pth string, info os.FileInfo) bool {
+		return info.Name() == "skip" || strings.HasSuffix(pth, ".log")
+	}
+
+	size, files, err := scanSize(src, exclude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 6 || files != 2 {
+		t.Errorf("scan of %d bytes, %d files, want 6, 2", size, files)
+	}
+
+	// without manifest, the totals are scanned
+	updates, progress := recordProgress(t)
+	err = compress(context.Background(), src, ioutil.Discard, BACKUP_CODEC_GZIP, exclude, nil,
+		newProgressTracker("backup", progress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkProgress(t, "backup", *updates, 6, 2)
+}
+
+func TestProgressReporter(t *testing.T) {
+	p := &Progress{Op: "backup", Bytes: 50, TotalBytes: 100, Files: 1, TotalFiles: 2, Started: time.Now().Add(-time.Second)}
+
+	var buf bytes.Buffer
+	report := NewProgressReporter(&buf, false, time.Hour)
+	report(p)
+	report(p) // before interval
+	p.Bytes, p.Files, p.Done = 100, 2, true
+	report(p)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("log lines %q, want 2", lines)
+	}
+	if !strings.HasPrefix(lines[0], "backup:  50%, 50 B/100 B, 1/2 files, ") {
+		t.Errorf("log line %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "backup: 100%, 100 B/100 B, 2/2 files, ") || !strings.Contains(lines[1], " in ") {
+		t.Errorf("done log line %q", lines[1])
+	}
+
+	buf.Reset()
+	report = NewProgressReporter(&buf, true, time.Hour)
+	p.Bytes, p.Files, p.Done = 50, 1, false
+	report(p)
+	if out := buf.String(); !strings.HasPrefix(out, "\r[===============>              ]  50%") || strings.HasSuffix(out, "\n") {
+		t.Errorf("bar %q", out)
+	}
+	buf.Reset()
+	p.Done = true
+	report(p)
+	if out := buf.String(); !strings.HasSuffix(out, "\n") {
+		t.Errorf("done bar %q doesn't end the line", out)
+	}
+}
+
+func TestProgressUnknownTotal(t *testing.T) {
+	p := &Progress{Bytes: 10, Started: time.Now().Add(-time.Second)}
+	if percent := p.Percent(); percent != -1 {
+		t.Errorf("percent %v, want -1", percent)
+	}
+	if eta := p.ETA(); eta != -1 {
+		t.Errorf("ETA %v, want -1", eta)
+	}
+	if bar := progressBar(p, 4); bar != "[????]" {
+		t.Errorf("bar %q", bar)
+	}
+}
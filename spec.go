@@ -0,0 +1,441 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/moisespsena-go/error-wrap"
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is the declarative enviroments specification (goenv.yaml).
+type Spec struct {
+	Environments []*EnvSpec `yaml:"environments"`
+}
+
+// EnvSpec is the specification of enviroment.
+type EnvSpec struct {
+	Name string `yaml:"name"`
+	// Go is the Go version, as "1.22", "1.22.3" or "sys".
+	Go  string            `yaml:"go,omitempty"`
+	Env map[string]string `yaml:"env,omitempty"`
+	// Repos are the git repositories cloned into src.
+	Repos []*RepoSpec `yaml:"repos,omitempty"`
+	// Tools are the tools manifest lines (see Tool), as
+	// "golang.org/x/tools/cmd/goimports@v0.20.0".
+	Tools   []string `yaml:"tools,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+// RepoSpec is a git repository cloned into src.
+type RepoSpec struct {
+	URL string `yaml:"url"`
+	// Path is relative to src. Defaults to URL host and path, as
+	// "github.com/me/project".
+	Path string `yaml:"path,omitempty"`
+	// Ref is the branch or tag cloned.
+	Ref string `yaml:"ref,omitempty"`
+}
+
+// SrcPath returns the Path or the path derived from URL.
+func (r *RepoSpec) SrcPath() string {
+	if r.Path != "" {
+		return r.Path
+	}
+	u := r.URL
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+3:]
+	} else if i := strings.Index(u, "@"); i >= 0 {
+		// git@github.com:me/project.git
+		u = strings.Replace(u[i+1:], ":", "/", 1)
+	}
+	if i := strings.Index(u, "@"); i >= 0 && i < strings.Index(u, "/") {
+		u = u[i+1:]
+	}
+	return path.Clean(strings.TrimSuffix(u, ".git"))
+}
+
+// check fails if the URL is an option of git clone or the path isn't
+// relative to src, as "../x" or "/x".
+func (r *RepoSpec) check() error {
+	if r.URL == "" || r.URL[0] == '-' {
+		return fmt.Errorf("Invalid repository URL %q.", r.URL)
+	}
+	p := r.SrcPath()
+	clean := path.Clean(filepath.ToSlash(p))
+	if path.IsAbs(clean) || filepath.IsAbs(p) || filepath.VolumeName(p) != "" ||
+		clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("Repository path %q isn't relative to src.", p)
+	}
+	return nil
+}
+
+func ReadSpec(reader io.Reader) (spec *Spec, err error) {
+	spec = &Spec{}
+	dec := yaml.NewDecoder(reader)
+	dec.KnownFields(true)
+	if err = dec.Decode(spec); err != nil && err != io.EOF {
+		return nil, errwrap.Wrap(err, "Decode spec")
+	}
+	seen := map[string]bool{}
+	for i, e := range spec.Environments {
+		if e.Name == "" || strings.ContainsAny(e.Name, `/\`) || e.Name[0] == '.' {
+			return nil, fmt.Errorf("Enviroment %d: invalid name %q.", i, e.Name)
+		}
+		if seen[e.Name] {
+			return nil, fmt.Errorf("Enviroment %q is duplicated.", e.Name)
+		}
+		seen[e.Name] = true
+		// the settings are saved as lines
+		for key, value := range e.Env {
+			if err = checkEnvVar(key, value); err != nil {
+				return nil, errwrap.Wrap(err, "Enviroment %q", e.Name)
+			}
+		}
+		for _, line := range append(append([]string{}, e.Tools...), e.Exclude...) {
+			if strings.ContainsAny(line, "\r\n") {
+				return nil, fmt.Errorf("Enviroment %q: %q has line break.", e.Name, line)
+			}
+		}
+		for _, repo := range e.Repos {
+			if err = repo.check(); err != nil {
+				return nil, fmt.Errorf("Enviroment %q: %w", e.Name, err)
+			}
+		}
+	}
+	return spec, nil
+}
+
+func (s *Spec) Write(writer io.Writer) error {
+	enc := yaml.NewEncoder(writer)
+	enc.SetIndent(2)
+	if err := enc.Encode(s); err != nil {
+		return errwrap.Wrap(err, "Encode spec")
+	}
+	return enc.Close()
+}
+
+// readSettings returns the non empty lines of settings file of enviroment dir
+// pth.
+func readSettings(pth, name string) (lines []string, err error) {
+	p := filepath.Join(pth, SETTINGS_DIR, name)
+	if ok, err := IsFile(p); err != nil || !ok {
+		return nil, err
+	}
+	all, err := readLines(p)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range all {
+		if line != "" && line[0] != '#' {
+			lines = append(lines, line)
+		}
+	}
+	return
+}
+
+// writeSettings writes the lines to settings file of enviroment dir pth. If
+// lines is empty, the file is removed.
+func writeSettings(pth, name string, lines ...string) error {
+	p := filepath.Join(pth, SETTINGS_DIR, name)
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return appendSettings(pth, name, lines...)
+}
+
+func envVarsLines(vars map[string]string) (lines []string) {
+	for key, value := range vars {
+		lines = append(lines, key+"="+value)
+	}
+	sort.Strings(lines)
+	return
+}
+
+// ExportSpec returns the specification of enviroment.
+func (env *GoEnv) ExportSpec(name string) (spec *EnvSpec, err error) {
+	env, unlock, err := env.LockEnv(true, name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	pth, err := env.GetCheck(name)
+	if err != nil {
+		return nil, err
+	}
+	spec = &EnvSpec{Name: name}
+	b, err := readGoVersionBinding(pth)
+	if err != nil {
+		return nil, err
+	}
+	spec.Go = b.Name()
+
+	vars, err := readEnvSettings(pth)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range vars {
+		if spec.Env == nil {
+			spec.Env = map[string]string{}
+		}
+		spec.Env[v[0]] = v[1]
+	}
+	if spec.Exclude, err = readSettings(pth, "backup_exclude"); err != nil {
+		return nil, err
+	}
+	tools, err := env.Tools(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, tool := range tools {
+		spec.Tools = append(spec.Tools, tool.String())
+	}
+	if spec.Repos, err = gitRepos(filepath.Join(pth, "src")); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// gitRepos returns the git repositories of src dir.
+func gitRepos(src string) (repos []*RepoSpec, err error) {
+	if ok, err := IsDir(src); err != nil || !ok {
+		return nil, err
+	}
+	err = filepath.Walk(src, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if ok, _ := IsDir(pth, ".git"); !ok {
+			return nil
+		}
+		rel, err := filepath.Rel(src, pth)
+		if err != nil {
+			return err
+		}
+		repo := &RepoSpec{Path: filepath.ToSlash(rel)}
+		if repo.URL, err = git(pth, "remote", "get-url", "origin"); err != nil {
+			// local repository
+			return filepath.SkipDir
+		}
+		if ref, err := git(pth, "rev-parse", "--abbrev-ref", "HEAD"); err == nil && ref != "HEAD" {
+			repo.Ref = ref
+		}
+		if (&RepoSpec{URL: repo.URL}).SrcPath() == repo.Path {
+			repo.Path = ""
+		}
+		repos = append(repos, repo)
+		return filepath.SkipDir
+	})
+	return
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// SpecChange is a change to reconcile the database with the specification.
+type SpecChange struct {
+	Env string
+	// Op is "+" (add), "~" (change) or "-" (remove).
+	Op    string
+	Field string
+	From  string
+	To    string
+	// apply runs on the locked enviroment of ApplySpec.
+	apply func(env *GoEnv, stdout, stderr io.Writer) error
+}
+
+func (c *SpecChange) String() string {
+	s := c.Op + " " + c.Env
+	if c.Field != "" {
+		s += " " + c.Field + ":"
+	}
+	switch {
+	case c.From != "" && c.To != "":
+		s += " " + c.From + " -> " + c.To
+	case c.From != "":
+		s += " " + c.From
+	case c.To != "":
+		s += " " + c.To
+	}
+	return s
+}
+
+// goVersionSatisfies reports whether the bound version name satisfies the
+// spec version, as "go1.22.3" satisfies "1.22".
+func goVersionSatisfies(bound, version string) bool {
+	version = strings.ToLower(version)
+	if bound == version {
+		return true
+	}
+	name := "go" + strings.TrimPrefix(version, "go")
+	return bound == name || strings.HasPrefix(bound, name+".")
+}
+
+// PlanSpec returns the changes to reconcile the database with spec. If
+// prune is true, the enviroments not specified are removed (moved to trash).
+func (env *GoEnv) PlanSpec(spec *Spec, prune bool) (changes []*SpecChange, err error) {
+	names, err := env.Ls()
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]bool{}
+	for _, name := range names {
+		existing[name] = true
+	}
+
+	for _, e := range spec.Environments {
+		e := e
+		current := &EnvSpec{Name: e.Name}
+		pth := filepath.Join(env.DbDir, e.Name)
+		if existing[e.Name] {
+			if current, err = env.ExportSpec(e.Name); err != nil {
+				return nil, fmt.Errorf("Export %q: %w", e.Name, err)
+			}
+		} else {
+			changes = append(changes, &SpecChange{Env: e.Name, Op: "+", apply: func(env *GoEnv, stdout, stderr io.Writer) error {
+				return env.InitWith(e.Name, &InitOptions{GoVersion: e.Go, Stdout: stdout, Stderr: stderr})
+			}})
+			current.Go = e.Go
+		}
+
+		if e.Go != "" && !goVersionSatisfies(current.Go, e.Go) {
+			changes = append(changes, &SpecChange{Env: e.Name, Op: "~", Field: "go", From: current.Go, To: e.Go,
+				apply: func(env *GoEnv, stdout, stderr io.Writer) error {
+					vs := NewGoVersions(env)
+					version, err := vs.Resolve(e.Go, true)
+					if err != nil {
+						return err
+					}
+					return vs.Set(version, e.Name)
+				}})
+		}
+
+		if from, to := envVarsLines(current.Env), envVarsLines(e.Env); strings.Join(from, " ") != strings.Join(to, " ") {
+			changes = append(changes, &SpecChange{Env: e.Name, Op: "~", Field: "env",
+				From: strings.Join(from, " "), To: strings.Join(to, " "),
+				apply: func(env *GoEnv, stdout, stderr io.Writer) error {
+					if err := writeSettings(pth, ENV_SETTINGS_NAME, to...); err != nil {
+						return err
+					}
+					_, err := env.Update(e.Name)
+					return err
+				}})
+		}
+
+		if from, to := strings.Join(current.Exclude, " "), strings.Join(e.Exclude, " "); from != to {
+			changes = append(changes, &SpecChange{Env: e.Name, Op: "~", Field: "exclude", From: from, To: to,
+				apply: func(env *GoEnv, stdout, stderr io.Writer) error {
+					return writeSettings(pth, "backup_exclude", e.Exclude...)
+				}})
+		}
+
+		for _, repo := range e.Repos {
+			repo := repo
+			if err = repo.check(); err != nil {
+				return nil, fmt.Errorf("Enviroment %q: %w", e.Name, err)
+			}
+			dir := filepath.Join(pth, "src", filepath.FromSlash(repo.SrcPath()))
+			if ok, err := IsDir(dir); err != nil {
+				return nil, err
+			} else if ok {
+				continue
+			}
+			changes = append(changes, &SpecChange{Env: e.Name, Op: "+", Field: "repo", To: repo.SrcPath(),
+				apply: func(env *GoEnv, stdout, stderr io.Writer) error {
+					args := []string{"clone"}
+					if repo.Ref != "" {
+						args = append(args, "--branch", repo.Ref)
+					}
+					cmd := exec.Command("git", append(args, "--", repo.URL, dir)...)
+					cmd.Stdout, cmd.Stderr = stdout, stderr
+					return errwrap.Wrap(cmd.Run(), "git clone %q", repo.URL)
+				}})
+		}
+
+		declared := map[string]bool{}
+		for _, line := range current.Tools {
+			declared[line] = true
+		}
+		for _, line := range e.Tools {
+			tool, err := ParseTool(line)
+			if err != nil {
+				return nil, errwrap.Wrap(err, "Enviroment %q", e.Name)
+			}
+			if declared[tool.String()] {
+				continue
+			}
+			changes = append(changes, &SpecChange{Env: e.Name, Op: "+", Field: "tool", To: tool.String(),
+				apply: func(env *GoEnv, stdout, stderr io.Writer) error {
+					if err := env.AddTool(e.Name, tool); err != nil {
+						return err
+					}
+					return env.InstallTool(e.Name, tool, stdout, stderr)
+				}})
+		}
+	}
+
+	if prune {
+		specified := map[string]bool{}
+		for _, e := range spec.Environments {
+			specified[e.Name] = true
+		}
+		for _, name := range names {
+			name := name
+			if !specified[name] {
+				changes = append(changes, &SpecChange{Env: name, Op: "-", apply: func(env *GoEnv, stdout, stderr io.Writer) error {
+					_, err := env.Rm(name, false)
+					return err
+				}})
+			}
+		}
+	}
+	return
+}
+
+// ApplySpec applies the changes returned by PlanSpec.
+func (env *GoEnv) ApplySpec(changes []*SpecChange, stdout, stderr io.Writer) error {
+	names := make([]string, len(changes))
+	for i, c := range changes {
+		names[i] = c.Env
+	}
+	env, unlock, err := env.LockEnv(false, names...)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, c := range changes {
+		if err := c.apply(env, stdout, stderr); err != nil {
+			return fmt.Errorf("Apply %q: %w", c.String(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadSpec(t *testing.T) {
+	spec, err := ReadSpec(strings.NewReader(`environments:
+  - name: web
+    go: "1.22"
+    env:
+      CGO_ENABLED: "0"
+      GOFLAGS: "-mod=mod -trimpath"
+    exclude: ["*.log"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spec.Environments) != 1 || spec.Environments[0].Env["GOFLAGS"] != "-mod=mod -trimpath" {
+		t.Errorf("unexpected spec: %+v", spec.Environments)
+	}
+}
+
+func TestReadSpecInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"env name":     "environments: [{name: ../web}]",
+		"duplicated":   "environments: [{name: web}, {name: web}]",
+		"var name":     "environments: [{name: web, env: {\"A=B\": x}}]",
+		"var digit":    "environments: [{name: web, env: {1A: x}}]",
+		"var newline":  "environments: [{name: web, env: {A: \"x\\nB=y\"}}]",
+		"exclude line": "environments: [{name: web, exclude: [\"a\\nb\"]}]",
+		"tool line":    "environments: [{name: web, tools: [\"a\\rb\"]}]",
+		"unknown":      "environments: [{name: web, foo: bar}]",
+		"repo parent":  "environments: [{name: web, repos: [{url: \"https://h/r\", path: ../../x}]}]",
+		"repo abs":     "environments: [{name: web, repos: [{url: \"https://h/r\", path: /x}]}]",
+		"repo src":     "environments: [{name: web, repos: [{url: \"https://h/r\", path: a/../.}]}]",
+		"repo url dir": "environments: [{name: web, repos: [{url: \"https://h/../../x\"}]}]",
+		"repo option":  "environments: [{name: web, repos: [{url: \"--upload-pack=touch x\", path: x}]}]",
+	} {
+		if _, err := ReadSpec(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: invalid spec is accepted", name)
+		}
+	}
+}
+
+func TestWriteSettingsLineBreak(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeSettings(dir, ENV_SETTINGS_NAME, "A=x", "B=y\nC=z"); err == nil {
+		t.Fatal("line with line break is written")
+	}
+	if lines, err := readSettings(dir, ENV_SETTINGS_NAME); err != nil || len(lines) != 0 {
+		t.Errorf("settings = %q, %v, want empty", lines, err)
+	}
+}
+
+// snapshotDB returns the files and contents of database.
+func snapshotDB(t *testing.T, env *GoEnv) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.Walk(env.DbDir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := ioutil.ReadFile(pth)
+		files[pth] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func planSpec(t *testing.T, env *GoEnv, spec *Spec, prune bool) (lines []string, changes []*SpecChange) {
+	t.Helper()
+	changes, err := env.PlanSpec(spec, prune)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+	return
+}
+
+func TestApplySpec(t *testing.T) {
+	env := newTestEnv(t, "e1", "e2")
+	spec := &Spec{Environments: []*EnvSpec{
+		{Name: "e1", Env: map[string]string{"CGO_ENABLED": "0"}, Exclude: []string{"*.log"}},
+		{Name: "e3", Env: map[string]string{"GOFLAGS": "-mod=mod"}},
+	}}
+
+	// the plan (apply --diff) doesn't changes the database
+	before := snapshotDB(t, env)
+	lines, _ := planSpec(t, env, spec, true)
+	want := []string{
+		"~ e1 env: CGO_ENABLED=0",
+		"~ e1 exclude: *.log",
+		"+ e3",
+		"~ e3 env: GOFLAGS=-mod=mod",
+		"- e2",
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("changes %q, want %q", lines, want)
+	}
+	if after := snapshotDB(t, env); !reflect.DeepEqual(before, after) {
+		t.Error("plan changes the database")
+	}
+
+	// without prune, the enviroments not specified are kept
+	lines, changes := planSpec(t, env, spec, false)
+	if !reflect.DeepEqual(lines, want[:4]) {
+		t.Errorf("changes without prune %q, want %q", lines, want[:4])
+	}
+	if err := env.ApplySpec(changes, ioutil.Discard, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := env.Ls(); !reflect.DeepEqual(names, []string{"e1", "e2", "e3"}) {
+		t.Errorf("enviroments %q, want e1 e2 e3", names)
+	}
+	for name, e := range map[string]*EnvSpec{"e1": spec.Environments[0], "e3": spec.Environments[1]} {
+		exported, err := env.ExportSpec(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(exported.Env, e.Env) || !reflect.DeepEqual(exported.Exclude, e.Exclude) {
+			t.Errorf("%s: exported %+v, want %+v", name, exported, e)
+		}
+	}
+
+	// the applied spec has no changes
+	if lines, _ = planSpec(t, env, spec, false); len(lines) != 0 {
+		t.Errorf("changes of applied spec %q", lines)
+	}
+
+	lines, changes = planSpec(t, env, spec, true)
+	if !reflect.DeepEqual(lines, []string{"- e2"}) {
+		t.Errorf("prune changes %q, want [- e2]", lines)
+	}
+	if err := env.ApplySpec(changes, ioutil.Discard, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := env.Ls(); !reflect.DeepEqual(names, []string{"e1", "e3"}) {
+		t.Errorf("enviroments after prune %q, want e1 e3", names)
+	}
+	if lines, _ = planSpec(t, env, spec, true); len(lines) != 0 {
+		t.Errorf("changes of applied and pruned spec %q", lines)
+	}
+}
+
+func TestApplySpecRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	repo := filepath.Join(t.TempDir(), "repo")
+	writeTestFile(t, filepath.Join(repo, "main.go"), "package main\n")
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"add", "main.go"},
+		{"-c", "user.name=test", "-c", "user.email=test@localhost", "commit", "-q", "-m", "init"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", args[0], err, out)
+		}
+	}
+
+	env := newTestEnv(t, "e1")
+	spec := &Spec{Environments: []*EnvSpec{
+		{Name: "e1", Repos: []*RepoSpec{{URL: repo, Path: "example.com/repo"}}},
+	}}
+	lines, changes := planSpec(t, env, spec, false)
+	if !reflect.DeepEqual(lines, []string{"+ e1 repo: example.com/repo"}) {
+		t.Errorf("changes %q", lines)
+	}
+	if err := env.ApplySpec(changes, ioutil.Discard, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := IsFile(env.DbDir, "e1", "src", "example.com", "repo", "main.go"); !ok {
+		t.Error("repository isn't cloned")
+	}
+	if lines, _ = planSpec(t, env, spec, false); len(lines) != 0 {
+		t.Errorf("changes of applied spec %q", lines)
+	}
+
+	// the specs built without ReadSpec are also checked
+	for _, r := range []*RepoSpec{
+		{URL: "--upload-pack=touch x", Path: "x"},
+		{URL: repo, Path: "../../x"},
+	} {
+		spec.Environments[0].Repos = []*RepoSpec{r}
+		if _, err := env.PlanSpec(spec, false); err == nil {
+			t.Errorf("repo %+v is planned", *r)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moisespsena-go/error-wrap"
+)
+
+// BackupStoreItem is a backup file saved on BackupStore.
+type BackupStoreItem struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// BackupStore is a destination of backup files. The names are relative to
+// the store root and uses '/' as separator.
+type BackupStore interface {
+	Put(name string, reader io.Reader) error
+	Get(name string) (io.ReadCloser, error)
+	List(prefix string) ([]*BackupStoreItem, error)
+	Delete(name string) error
+}
+
+// BackupStoreFactory creates a BackupStore from URL.
+type BackupStoreFactory func(u *url.URL) (BackupStore, error)
+
+// BackupStoreFactories maps the URL schemes to the store factories.
+var BackupStoreFactories = map[string]BackupStoreFactory{
+	"file": func(u *url.URL) (BackupStore, error) {
+		return NewDirBackupStore(u.Path), nil
+	},
+	"s3":      NewS3BackupStore,
+	"sftp":    NewSFTPBackupStore,
+	"webdav":  NewWebDAVBackupStore,
+	"webdavs": NewWebDAVBackupStore,
+}
+
+// IsBackupStoreURL reports whether s is an URL with scheme registered on
+// BackupStoreFactories.
+func IsBackupStoreURL(s string) bool {
+	i := strings.Index(s, "://")
+	if i <= 0 {
+		return false
+	}
+	_, ok := BackupStoreFactories[s[0:i]]
+	return ok
+}
+
+// OpenBackupStore opens the store of URL. If the last path element of URL
+// looks like a backup file (contains ".tar"), it is returned as name and
+// removed from store root.
+func OpenBackupStore(s string) (store BackupStore, name string, err error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, "", errwrap.Wrap(err, "Parse URL %q", s)
+	}
+	factory, ok := BackupStoreFactories[u.Scheme]
+	if !ok {
+		return nil, "", fmt.Errorf("Unsupported backup store scheme %q.", u.Scheme)
+	}
+	if base := path.Base(u.Path); strings.Contains(base, ".tar") && !strings.HasSuffix(u.Path, "/") {
+		name = base
+		u.Path = path.Dir(u.Path)
+	}
+	if store, err = factory(u); err != nil {
+		return nil, "", errwrap.Wrap(err, "Open backup store %q", s)
+	}
+	return
+}
+
+func backupStoreLocation(storeURL, name string) string {
+	u, err := url.Parse(storeURL)
+	if err != nil {
+		return name
+	}
+	if !strings.HasSuffix(u.Path, "/"+name) {
+		u.Path = path.Join(u.Path, name)
+	}
+	return u.String()
+}
+
+// CloseBackupStore closes the store if it implements io.Closer.
+func CloseBackupStore(store BackupStore) error {
+	if c, ok := store.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// DirBackupStore is a BackupStore for local directory.
+type DirBackupStore struct {
+	Dir string
+}
+
+func NewDirBackupStore(dir string) *DirBackupStore {
+	return &DirBackupStore{dir}
+}
+
+// checkStoreName checks that the name is relative to store root and doesn't
+// escape from it.
+func checkStoreName(name string) error {
+	clean := path.Clean(strings.Replace(name, `\`, "/", -1))
+	if name == "" || clean == "." || path.IsAbs(clean) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" ||
+		clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("Invalid backup store name %q.", name)
+	}
+	return nil
+}
+
+func (s *DirBackupStore) path(name string) (string, error) {
+	if err := checkStoreName(name); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(name)), nil
+}
+
+func (s *DirBackupStore) Put(name string, reader io.Reader) (err error) {
+	pth, err := s.path(name)
+	if err != nil {
+		return
+	}
+	if err = MkdirAll(filepath.Dir(pth)); err != nil {
+		return
+	}
+	f, err := ioutil.TempFile(filepath.Dir(pth), "."+filepath.Base(pth)+".")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err = io.Copy(f, reader); err != nil {
+		return errwrap.Wrap(err, "Write %q", pth)
+	}
+	if err = f.Close(); err != nil {
+		return errwrap.Wrap(err, "Close %q", pth)
+	}
+	return os.Rename(f.Name(), pth)
+}
+
+func (s *DirBackupStore) Get(name string) (io.ReadCloser, error) {
+	pth, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(pth)
+}
+
+func (s *DirBackupStore) List(prefix string) (items []*BackupStoreItem, err error) {
+	exists, err := IsDir(s.Dir)
+	if err != nil || !exists {
+		return nil, err
+	}
+	err = filepath.Walk(s.Dir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+		name, err := filepath.Rel(s.Dir, pth)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
+		if strings.HasPrefix(name, prefix) {
+			items = append(items, &BackupStoreItem{name, info.Size(), info.ModTime()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errwrap.Wrap(err, "List %q", s.Dir)
+	}
+	sortBackupStoreItems(items)
+	return
+}
+
+func (s *DirBackupStore) Delete(name string) error {
+	pth, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(pth)
+}
+
+func sortBackupStoreItems(items []*BackupStoreItem) {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+}
@@ -0,0 +1,297 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moisespsena-go/error-wrap"
+)
+
+// S3BackupStore is a BackupStore for S3 compatible services.
+//
+// The URL format is s3://BUCKET/PREFIX. The query parameters "endpoint" and
+// "region" overrides the AWS_ENDPOINT_URL and AWS_REGION enviroment
+// variables. The credentials are read from AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN enviroment variables.
+type S3BackupStore struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Client          *http.Client
+}
+
+func NewS3BackupStore(u *url.URL) (BackupStore, error) {
+	s := &S3BackupStore{
+		Bucket:          u.Host,
+		Prefix:          strings.Trim(u.Path, "/"),
+		Endpoint:        u.Query().Get("endpoint"),
+		Region:          u.Query().Get("region"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Client:          http.DefaultClient,
+	}
+	if s.Bucket == "" {
+		return nil, fmt.Errorf("Bucket name isn't defined.")
+	}
+	if s.Region == "" {
+		if s.Region = os.Getenv("AWS_REGION"); s.Region == "" {
+			if s.Region = os.Getenv("AWS_DEFAULT_REGION"); s.Region == "" {
+				s.Region = "us-east-1"
+			}
+		}
+	}
+	if s.Endpoint == "" {
+		if s.Endpoint = os.Getenv("AWS_ENDPOINT_URL"); s.Endpoint == "" {
+			s.Endpoint = "https://s3." + s.Region + ".amazonaws.com"
+		}
+	}
+	s.Endpoint = strings.TrimSuffix(s.Endpoint, "/")
+	return s, nil
+}
+
+func (s *S3BackupStore) key(name string) string {
+	if s.Prefix == "" {
+		return name
+	}
+	return s.Prefix + "/" + name
+}
+
+func (s *S3BackupStore) Put(name string, reader io.Reader) error {
+	// S3 requires the content length, so the data is spooled to temp file.
+	f, err := ioutil.TempFile("", "goenv-s3-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	size, err := io.Copy(f, reader)
+	if err != nil {
+		return errwrap.Wrap(err, "Spool %q", name)
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	r, err := s.do(http.MethodPut, s.key(name), nil, ioutil.NopCloser(f), size)
+	if err != nil {
+		return err
+	}
+	return r.Body.Close()
+}
+
+func (s *S3BackupStore) Get(name string) (io.ReadCloser, error) {
+	r, err := s.do(http.MethodGet, s.key(name), nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	return r.Body, nil
+}
+
+func (s *S3BackupStore) Delete(name string) error {
+	r, err := s.do(http.MethodDelete, s.key(name), nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	return r.Body.Close()
+}
+
+func (s *S3BackupStore) List(prefix string) (items []*BackupStoreItem, err error) {
+	var result struct {
+		Contents []struct {
+			Key          string
+			Size         int64
+			LastModified time.Time
+		}
+		IsTruncated           bool
+		NextContinuationToken string
+	}
+	keyPrefix := s.key(prefix)
+	if prefix == "" && s.Prefix != "" {
+		keyPrefix += "/"
+	}
+	query := url.Values{"list-type": {"2"}, "prefix": {keyPrefix}}
+	for {
+		r, err := s.do(http.MethodGet, "", query, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		result.Contents, result.IsTruncated = nil, false
+		err = xml.NewDecoder(r.Body).Decode(&result)
+		r.Body.Close()
+		if err != nil {
+			return nil, errwrap.Wrap(err, "Decode list of %q", s.Bucket)
+		}
+		for _, c := range result.Contents {
+			name := strings.TrimPrefix(c.Key, s.Prefix)
+			name = strings.TrimPrefix(name, "/")
+			items = append(items, &BackupStoreItem{name, c.Size, c.LastModified})
+		}
+		if !result.IsTruncated {
+			break
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+	sortBackupStoreItems(items)
+	return
+}
+
+func (s *S3BackupStore) do(method, key string, query url.Values, body io.ReadCloser, size int64) (*http.Response, error) {
+	u, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, errwrap.Wrap(err, "Parse endpoint %q", s.Endpoint)
+	}
+	u.Path = "/" + s.Bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	u.RawPath = s3Escape(u.Path, false)
+	u.RawQuery = s3Query(query)
+
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Body = body
+		req.ContentLength = size
+	}
+	s.sign(req, time.Now().UTC())
+
+	r, err := s.Client.Do(req)
+	if err != nil {
+		return nil, errwrap.Wrap(err, "S3 %s %q", method, u.Path)
+	}
+	if r.StatusCode/100 != 2 {
+		defer r.Body.Close()
+		var e struct {
+			Code    string
+			Message string
+		}
+		xml.NewDecoder(r.Body).Decode(&e)
+		if r.StatusCode == http.StatusNotFound && key != "" {
+			return nil, fmt.Errorf("S3 %s %q: %w", method, u.Path, os.ErrNotExist)
+		}
+		return nil, fmt.Errorf("S3 %s %q: %s: %s %s", method, u.Path, r.Status, e.Code, e.Message)
+	}
+	return r, nil
+}
+
+// sign signs the request using AWS Signature Version 4.
+func (s *S3BackupStore) sign(req *http.Request, now time.Time) {
+	const payload = "UNSIGNED-PAYLOAD"
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[0:8]
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payload)
+	if s.SessionToken != "" {
+		req.Header.Set("x-amz-security-token", s.SessionToken)
+	}
+
+	headers := []string{"host"}
+	values := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") {
+			headers = append(headers, name)
+			values[name] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	sort.Strings(headers)
+	var canonicalHeaders string
+	for _, name := range headers {
+		canonicalHeaders += name + ":" + values[name] + "\n"
+	}
+	signedHeaders := strings.Join(headers, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payload,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func sha256Hex(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Escape escapes s as defined by AWS URI encoding rules.
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func s3Query(query url.Values) string {
+	var keys []string
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		parts = append(parts, s3Escape(key, true)+"="+s3Escape(query.Get(key), true))
+	}
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,190 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// s3Stub is an in-memory S3 bucket. The list responses have one key per
+// page, so the pagination is exercised.
+type s3Stub struct {
+	Bucket  string
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+type s3StubList struct {
+	XMLName  xml.Name `xml:"ListBucketResult"`
+	Contents []struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+	}
+	IsTruncated           bool
+	NextContinuationToken string `xml:",omitempty"`
+}
+
+func newS3Stub(t *testing.T, bucket string) (*s3Stub, *httptest.Server) {
+	stub := &s3Stub{Bucket: bucket, objects: map[string][]byte{}}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	return stub, srv
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+		r.Header.Get("x-amz-date") == "" {
+		s.error(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+	bucketPath := "/" + s.Bucket
+	if r.URL.Path != bucketPath && !strings.HasPrefix(r.URL.Path, bucketPath+"/") {
+		s.error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, bucketPath), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		s.list(w, r)
+	case r.Method == http.MethodPut:
+		if r.ContentLength < 0 {
+			s.error(w, http.StatusLengthRequired, "MissingContentLength")
+			return
+		}
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			s.error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		s.objects[key] = data
+	case r.Method == http.MethodGet:
+		data, ok := s.objects[key]
+		if !ok {
+			s.error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.error(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (s *s3Stub) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("list-type") != "2" {
+		s.error(w, http.StatusBadRequest, "InvalidArgument")
+		return
+	}
+	var keys []string
+	for key := range s.objects {
+		if strings.HasPrefix(key, query.Get("prefix")) && key > query.Get("continuation-token") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var result s3StubList
+	if len(keys) > 0 {
+		result.Contents = append(result.Contents, struct {
+			Key          string
+			Size         int64
+			LastModified time.Time
+		}{keys[0], int64(len(s.objects[keys[0]])), time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)})
+		if len(keys) > 1 {
+			result.IsTruncated, result.NextContinuationToken = true, keys[0]
+		}
+	}
+	xml.NewEncoder(w).Encode(&result)
+}
+
+func (s *s3Stub) error(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	xml.NewEncoder(w).Encode(&struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+	}{Code: code})
+}
+
+func TestS3BackupStore(t *testing.T) {
+	stub, srv := newS3Stub(t, "bucket")
+	store, name, err := OpenBackupStore("s3://bucket/prefix?endpoint=" + srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "" {
+		t.Errorf("name = %q, want empty", name)
+	}
+	testBackupStore(t, store)
+	if _, ok := stub.objects["prefix/e2/e2_1.tar.gz"]; !ok {
+		t.Errorf("object key hasn't prefix: %v", stub.objects)
+	}
+	if _, err = store.Get("missing.tar.gz"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Get missing = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestS3BackupAndRestore(t *testing.T) {
+	_, srv := newS3Stub(t, "bucket")
+	env := newTestEnv(t, "e1", "e2")
+	options := &BackupOptions{Target: "s3://bucket/backups?endpoint=" + srv.URL}
+
+	// the options are reused, so the second backup must not be saved with
+	// the name of first
+	locations := map[string]string{}
+	for _, name := range []string{"e1", "e2"} {
+		location, err := env.Backup(name, options)
+		if err != nil {
+			t.Fatalf("Backup %q: %v", name, err)
+		}
+		if !strings.Contains(location, "/backups/"+name+"_") {
+			t.Errorf("location of %q = %q", name, location)
+		}
+		locations[name] = location
+	}
+	if options.Store != nil || options.StoreName != "" {
+		t.Errorf("Backup changed the options: %+v", options)
+	}
+
+	for _, name := range []string{"e1", "e2"} {
+		pth, err := env.Restore(&RestoreOptions{Source: locations[name], Name: name + "_restored"})
+		if err != nil {
+			t.Fatalf("Restore %q: %v", name, err)
+		}
+		got := readTestFile(t, filepath.Join(pth, "src", name, "main.go"))
+		if want := "package main // " + name + "\n"; got != want {
+			t.Errorf("restored %q main.go = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/moisespsena-go/error-wrap"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SFTPBackupStore is a BackupStore for SFTP servers.
+//
+// The URL format is sftp://[USER[:PASSWORD]@]HOST[:PORT]/PATH. The
+// authentication uses the password of URL, the ssh-agent of SSH_AUTH_SOCK
+// and the private keys at ~/.ssh. The host keys are verified using
+// ~/.ssh/known_hosts or the file of "known_hosts" query parameter.
+type SFTPBackupStore struct {
+	Dir    string
+	conn   *ssh.Client
+	client *sftp.Client
+}
+
+func NewSFTPBackupStore(u *url.URL) (BackupStore, error) {
+	config, err := sftpClientConfig(u)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "22")
+	}
+	conn, err := ssh.Dial("tcp", host, config)
+	if err != nil {
+		return nil, errwrap.Wrap(err, "SSH connect to %q", host)
+	}
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, errwrap.Wrap(err, "SFTP session")
+	}
+	return &SFTPBackupStore{Dir: u.Path, conn: conn, client: client}, nil
+}
+
+func sftpClientConfig(u *url.URL) (*ssh.ClientConfig, error) {
+	knownHostsFile := u.Query().Get("known_hosts")
+	if knownHostsFile == "" {
+		knownHostsFile = "~/.ssh/known_hosts"
+	}
+	knownHostsFile, err := homedir.Expand(knownHostsFile)
+	if err != nil {
+		return nil, err
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, errwrap.Wrap(err, "Load known hosts")
+	}
+
+	user := u.User.Username()
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+
+	var auth []ssh.AuthMethod
+	if password, ok := u.User.Password(); ok {
+		auth = append(auth, ssh.Password(password))
+	}
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	var signers []ssh.Signer
+	for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+		pth, err := homedir.Expand(filepath.Join("~", ".ssh", name))
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadFile(pth)
+		if err != nil {
+			continue
+		}
+		if signer, err := ssh.ParsePrivateKey(data); err == nil {
+			signers = append(signers, signer)
+		}
+	}
+	if len(signers) > 0 {
+		auth = append(auth, ssh.PublicKeys(signers...))
+	}
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("No SSH authentication method available.")
+	}
+	return &ssh.ClientConfig{User: user, Auth: auth, HostKeyCallback: hostKeyCallback}, nil
+}
+
+func (s *SFTPBackupStore) path(name string) (string, error) {
+	if err := checkStoreName(name); err != nil {
+		return "", err
+	}
+	return path.Join(s.Dir, name), nil
+}
+
+func (s *SFTPBackupStore) Put(name string, reader io.Reader) (err error) {
+	pth, err := s.path(name)
+	if err != nil {
+		return
+	}
+	if err = s.client.MkdirAll(path.Dir(pth)); err != nil {
+		return errwrap.Wrap(err, "Create directory of %q", pth)
+	}
+	tmp := path.Join(path.Dir(pth), "."+path.Base(pth)+".part")
+	f, err := s.client.Create(tmp)
+	if err != nil {
+		return errwrap.Wrap(err, "Create %q", tmp)
+	}
+	if _, err = f.ReadFrom(reader); err != nil {
+		f.Close()
+		s.client.Remove(tmp)
+		return errwrap.Wrap(err, "Write %q", tmp)
+	}
+	if err = f.Close(); err != nil {
+		s.client.Remove(tmp)
+		return errwrap.Wrap(err, "Close %q", tmp)
+	}
+	return s.client.PosixRename(tmp, pth)
+}
+
+func (s *SFTPBackupStore) Get(name string) (io.ReadCloser, error) {
+	pth, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Open(pth)
+}
+
+func (s *SFTPBackupStore) List(prefix string) (items []*BackupStoreItem, err error) {
+	walker := s.client.Walk(s.Dir)
+	for walker.Step() {
+		if err = walker.Err(); err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, errwrap.Wrap(err, "List %q", walker.Path())
+		}
+		info := walker.Stat()
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(walker.Path(), s.Dir), "/")
+		if strings.HasPrefix(name, prefix) {
+			items = append(items, &BackupStoreItem{name, info.Size(), info.ModTime()})
+		}
+	}
+	sortBackupStoreItems(items)
+	return
+}
+
+func (s *SFTPBackupStore) Delete(name string) error {
+	pth, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	return s.client.Remove(pth)
+}
+
+func (s *SFTPBackupStore) Close() error {
+	s.client.Close()
+	return s.conn.Close()
+}
@@ -0,0 +1,153 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// startSFTPServer starts the SSH server with in-memory SFTP subsystem. The
+// user "test" is authenticated by the password "secret". Returns the URL of
+// dir.
+func startSFTPServer(t *testing.T, dir string) string {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if c.User() == "test" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, ssh.ErrNoAuth
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	// the file system is shared by all connections
+	handlers := sftp.InMemHandler()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveSFTP(conn, config, handlers)
+		}
+	}()
+
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(l.Addr().String())}, hostKey.PublicKey())
+	writeTestFile(t, knownHosts, line+"\n")
+	t.Setenv("SSH_AUTH_SOCK", "")
+	return "sftp://test:secret@" + l.Addr().String() + dir + "?known_hosts=" + knownHosts
+}
+
+func serveSFTP(conn net.Conn, config *ssh.ServerConfig, handlers sftp.Handlers) {
+	defer conn.Close()
+	_, channels, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				req.Reply(ok, nil)
+			}
+		}()
+		server := sftp.NewRequestServer(channel, handlers)
+		go func() {
+			server.Serve()
+			server.Close()
+		}()
+	}
+}
+
+func TestSFTPBackupStore(t *testing.T) {
+	store, name, err := OpenBackupStore(startSFTPServer(t, "/backups"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseBackupStore(store)
+	if name != "" {
+		t.Errorf("name = %q, want empty", name)
+	}
+	testBackupStore(t, store)
+
+	if err = store.Put("../escape.tar.gz", strings.NewReader("x")); err == nil {
+		t.Error("Put out of store dir doesn't fails")
+	}
+	items, err := store.List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range items {
+		if strings.HasPrefix(item.Name, ".") || strings.Contains(item.Name, "/.") {
+			t.Errorf("temporary file %q listed", item.Name)
+		}
+	}
+}
+
+func TestSFTPBackupAndRestore(t *testing.T) {
+	u := startSFTPServer(t, "/backups")
+	env := newTestEnv(t, "e1")
+	i := strings.IndexByte(u, '?')
+	location, err := env.Backup("e1", &BackupOptions{Target: u[:i] + "/e1.tar.gz" + u[i:]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pth, err := env.Restore(&RestoreOptions{Source: location, Name: "e1_restored"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(pth, "src", "e1", "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "package main // e1\n" {
+		t.Errorf("restored main.go = %q", data)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// testBackupStore puts, gets, lists and deletes backups on store.
+func testBackupStore(t *testing.T, store BackupStore) {
+	t.Helper()
+	files := map[string]string{
+		"e1/e1_1.tar.gz": "one",
+		"e1/e1_2.tar.gz": "two",
+		"e2/e2_1.tar.gz": "three",
+	}
+	for name, data := range files {
+		if err := store.Put(name, strings.NewReader(data)); err != nil {
+			t.Fatalf("Put %q: %v", name, err)
+		}
+	}
+	for name, data := range files {
+		r, err := store.Get(name)
+		if err != nil {
+			t.Fatalf("Get %q: %v", name, err)
+		}
+		got, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("Read %q: %v", name, err)
+		}
+		if string(got) != data {
+			t.Errorf("Get %q = %q, want %q", name, got, data)
+		}
+	}
+
+	items, err := store.List("e1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	if got := strings.Join(names, ","); got != "e1/e1_1.tar.gz,e1/e1_2.tar.gz" {
+		t.Errorf("List = %s", got)
+	}
+	if items[1].Size != 3 {
+		t.Errorf("Size of %q = %d, want 3", items[1].Name, items[1].Size)
+	}
+
+	if err = store.Delete("e1/e1_1.tar.gz"); err != nil {
+		t.Fatal(err)
+	}
+	if items, err = store.List("e1/"); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Errorf("List after delete = %d items, want 1", len(items))
+	}
+	if _, err = store.Get("e1/e1_1.tar.gz"); err == nil {
+		t.Error("Get of deleted backup doesn't fails")
+	}
+}
+
+func TestDirBackupStore(t *testing.T) {
+	testBackupStore(t, NewDirBackupStore(t.TempDir()))
+}
+
+func TestDirBackupStoreInvalidName(t *testing.T) {
+	store := NewDirBackupStore(t.TempDir())
+	for _, name := range []string{"", "..", "../x.tar.gz", "a/../../x.tar.gz", "/etc/x.tar.gz"} {
+		if err := store.Put(name, strings.NewReader("x")); err == nil {
+			t.Errorf("Put %q doesn't fails", name)
+		}
+		if _, err := store.Get(name); err == nil {
+			t.Errorf("Get %q doesn't fails", name)
+		}
+		if err := store.Delete(name); err == nil {
+			t.Errorf("Delete %q doesn't fails", name)
+		}
+	}
+}
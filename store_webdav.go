@@ -0,0 +1,204 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/moisespsena-go/error-wrap"
+)
+
+// WebDAVBackupStore is a BackupStore for WebDAV servers.
+//
+// The URL format is webdav://[USER[:PASSWORD]@]HOST/PATH, or webdavs:// for
+// HTTPS.
+type WebDAVBackupStore struct {
+	URL    *url.URL
+	Client *http.Client
+}
+
+func NewWebDAVBackupStore(u *url.URL) (BackupStore, error) {
+	base := *u
+	base.Scheme = "http"
+	if u.Scheme == "webdavs" {
+		base.Scheme = "https"
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/") + "/"
+	base.RawQuery = ""
+	return &WebDAVBackupStore{URL: &base, Client: http.DefaultClient}, nil
+}
+
+func (s *WebDAVBackupStore) url(name string) string {
+	u := *s.URL
+	u.User = nil
+	u.Path = path.Join(u.Path, name)
+	if name == "" || strings.HasSuffix(name, "/") {
+		u.Path += "/"
+	}
+	return u.String()
+}
+
+func (s *WebDAVBackupStore) send(method, name string, body io.Reader, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.url(name), body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if s.URL.User != nil {
+		password, _ := s.URL.User.Password()
+		req.SetBasicAuth(s.URL.User.Username(), password)
+	}
+	r, err := s.Client.Do(req)
+	if err != nil {
+		return nil, errwrap.Wrap(err, "WebDAV %s %q", method, name)
+	}
+	return r, nil
+}
+
+func (s *WebDAVBackupStore) do(method, name string, body io.Reader, header http.Header) (*http.Response, error) {
+	r, err := s.send(method, name, body, header)
+	if err != nil {
+		return nil, err
+	}
+	if r.StatusCode/100 != 2 {
+		r.Body.Close()
+		if r.StatusCode == http.StatusNotFound {
+			return nil, errwrap.Wrap(os.ErrNotExist, "WebDAV %s %q", method, name)
+		}
+		return nil, fmt.Errorf("WebDAV %s %q: %s", method, name, r.Status)
+	}
+	return r, nil
+}
+
+func (s *WebDAVBackupStore) mkdirAll(dir string) error {
+	if dir == "." || dir == "" {
+		return nil
+	}
+	var parent string
+	for _, part := range strings.Split(dir, "/") {
+		parent += part + "/"
+		r, err := s.send("MKCOL", parent, nil, nil)
+		if err != nil {
+			return err
+		}
+		r.Body.Close()
+		// 405 Method Not Allowed: the collection already exists
+		if r.StatusCode/100 != 2 && r.StatusCode != http.StatusMethodNotAllowed {
+			return fmt.Errorf("WebDAV MKCOL %q: %s", parent, r.Status)
+		}
+	}
+	return nil
+}
+
+func (s *WebDAVBackupStore) Put(name string, reader io.Reader) error {
+	if err := s.mkdirAll(path.Dir(name)); err != nil {
+		return err
+	}
+	r, err := s.do(http.MethodPut, name, reader, nil)
+	if err != nil {
+		return err
+	}
+	return r.Body.Close()
+}
+
+func (s *WebDAVBackupStore) Get(name string) (io.ReadCloser, error) {
+	r, err := s.do(http.MethodGet, name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return r.Body, nil
+}
+
+func (s *WebDAVBackupStore) Delete(name string) error {
+	r, err := s.do(http.MethodDelete, name, nil, nil)
+	if err != nil {
+		return err
+	}
+	return r.Body.Close()
+}
+
+func (s *WebDAVBackupStore) List(prefix string) (items []*BackupStoreItem, err error) {
+	return s.list("", prefix)
+}
+
+func (s *WebDAVBackupStore) list(dir, prefix string) (items []*BackupStoreItem, err error) {
+	const propfind = `<?xml version="1.0" encoding="utf-8"?>` +
+		`<propfind xmlns="DAV:"><prop><resourcetype/><getcontentlength/><getlastmodified/></prop></propfind>`
+	r, err := s.do("PROPFIND", dir, strings.NewReader(propfind), http.Header{
+		"Depth":        {"1"},
+		"Content-Type": {"application/xml"},
+	})
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	var result struct {
+		Responses []struct {
+			Href string `xml:"href"`
+			Prop struct {
+				Collection    *struct{} `xml:"resourcetype>collection"`
+				ContentLength int64     `xml:"getcontentlength"`
+				LastModified  string    `xml:"getlastmodified"`
+			} `xml:"propstat>prop"`
+		} `xml:"response"`
+	}
+	if err = xml.NewDecoder(r.Body).Decode(&result); err != nil {
+		return nil, errwrap.Wrap(err, "Decode PROPFIND of %q", dir)
+	}
+
+	for _, resp := range result.Responses {
+		href, err := url.PathUnescape(resp.Href)
+		if err != nil {
+			href = resp.Href
+		}
+		if u, err := url.Parse(href); err == nil && u.IsAbs() {
+			href = u.Path
+		}
+		name := strings.TrimPrefix(href, s.URL.Path)
+		if strings.TrimSuffix(name, "/") == strings.TrimSuffix(dir, "/") {
+			continue
+		}
+		if resp.Prop.Collection != nil {
+			children, err := s.list(strings.TrimSuffix(name, "/")+"/", prefix)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, children...)
+			continue
+		}
+		if path.Base(name)[0] == '.' || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		modTime, _ := time.Parse(http.TimeFormat, resp.Prop.LastModified)
+		items = append(items, &BackupStoreItem{name, resp.Prop.ContentLength, modTime})
+	}
+	sortBackupStoreItems(items)
+	return
+}
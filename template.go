@@ -0,0 +1,380 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/moisespsena-go/error-wrap"
+)
+
+const (
+	// TEMPLATES_BASENAME is the templates directory of database.
+	TEMPLATES_BASENAME = ".templates"
+	// TEMPLATE_CONFIG_NAME is the config file in template root. It isn't
+	// copied to enviroment.
+	TEMPLATE_CONFIG_NAME = "template.toml"
+	// ENV_SETTINGS_NAME is the file, in SETTINGS_DIR, of enviroment variables
+	// exported by activate script, as KEY=VALUE lines.
+	ENV_SETTINGS_NAME = "env"
+)
+
+// TemplateConfig is the TEMPLATE_CONFIG_NAME file contents.
+type TemplateConfig struct {
+	// Go is the default Go version of enviroment.
+	Go string `toml:"go"`
+	// Env are the enviroment variables exported by activate script.
+	Env map[string]string `toml:"env"`
+	// Exclude are appended to backup exclude patterns of enviroment.
+	Exclude []string `toml:"exclude"`
+	// PostInit are the shell commands run into the activated enviroment
+	// after init, as "go install golang.org/x/tools/cmd/goimports@latest".
+	PostInit []string `toml:"post_init"`
+}
+
+// Template is an enviroment template saved into $DB/.templates as directory
+// or archive (.tar.gz or .tar) with single root directory.
+type Template struct {
+	Name    string
+	Path    string
+	Archive bool
+}
+
+var templateExts = []string{".tar.gz", ".tgz", ".tar"}
+
+// TemplatesDir returns the templates directory.
+func (env *GoEnv) TemplatesDir() string {
+	return filepath.Join(env.DbDir, TEMPLATES_BASENAME)
+}
+
+// Templates returns the available templates.
+func (env *GoEnv) Templates() (templates []*Template, err error) {
+	items, err := ioutil.ReadDir(env.TemplatesDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for _, f := range items {
+		if f.Name()[0] == '.' {
+			continue
+		}
+		pth := filepath.Join(env.TemplatesDir(), f.Name())
+		if f.IsDir() {
+			templates = append(templates, &Template{Name: f.Name(), Path: pth})
+			continue
+		}
+		for _, ext := range templateExts {
+			if strings.HasSuffix(f.Name(), ext) {
+				templates = append(templates, &Template{strings.TrimSuffix(f.Name(), ext), pth, true})
+				break
+			}
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return
+}
+
+// Template returns the template with name.
+func (env *GoEnv) Template(name string) (*Template, error) {
+	templates, err := env.Templates()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range templates {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("Template %q not found in %q.", name, env.TemplatesDir())
+}
+
+// open returns the template directory. If the template is an archive, it's
+// extracted into temporary directory removed by cleanup.
+func (t *Template) open(env *GoEnv) (dir string, cleanup func(), err error) {
+	cleanup = func() {}
+	if !t.Archive {
+		return t.Path, cleanup, nil
+	}
+	f, err := os.Open(t.Path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	bkp, err := NewBackupReader(f, false)
+	if err != nil {
+		return "", nil, errwrap.Wrap(err, "Read %q", t.Path)
+	}
+	root, err := bkp.GetRootName()
+	if err != nil {
+		return "", nil, errwrap.Wrap(err, "Read %q", t.Path)
+	}
+	tmpDir, err := env.TempDir()
+	if err != nil {
+		return "", nil, err
+	}
+	if tmpDir, err = ioutil.TempDir(tmpDir, "template-"+t.Name+"-"); err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { os.RemoveAll(tmpDir) }
+	if err = bkp.Extract(root, tmpDir, ExtractOptions(0)); err != nil {
+		cleanup()
+		return "", nil, errwrap.Wrap(err, "Extract %q", t.Path)
+	}
+	return filepath.Join(tmpDir, root), cleanup, nil
+}
+
+func readTemplateConfig(dir string) (config *TemplateConfig, err error) {
+	config = &TemplateConfig{}
+	pth := filepath.Join(dir, TEMPLATE_CONFIG_NAME)
+	if _, err = toml.DecodeFile(pth, config); err != nil && !os.IsNotExist(err) {
+		return nil, errwrap.Wrap(err, "Load %q", pth)
+	}
+	return config, nil
+}
+
+var envVarNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// checkEnvVar checks the enviroment variable exported by activate script.
+// The value can't have line breaks, because the settings are KEY=VALUE lines.
+func checkEnvVar(key, value string) error {
+	if !envVarNameRe.MatchString(key) {
+		return fmt.Errorf("Invalid variable name %q.", key)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("Value of variable %q has line break.", key)
+	}
+	return nil
+}
+
+// readEnvSettings returns the KEY, VALUE pairs of ENV_SETTINGS_NAME settings
+// file of enviroment dir pth.
+func readEnvSettings(pth string) (vars [][2]string, err error) {
+	p := filepath.Join(pth, SETTINGS_DIR, ENV_SETTINGS_NAME)
+	if ok, err := IsFile(p); err != nil || !ok {
+		return nil, err
+	}
+	lines, err := readLines(p)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("%q: invalid line %q.", p, line)
+		}
+		if err = checkEnvVar(parts[0], parts[1]); err != nil {
+			return nil, errwrap.Wrap(err, "%q", p)
+		}
+		vars = append(vars, [2]string{parts[0], parts[1]})
+	}
+	return
+}
+
+// appendSettings appends the lines to settings file of enviroment dir pth.
+func appendSettings(pth, name string, lines ...string) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	p := filepath.Join(pth, SETTINGS_DIR, name)
+	for _, line := range lines {
+		if strings.ContainsAny(line, "\r\n") {
+			return fmt.Errorf("%q: line %q has line break.", p, line)
+		}
+	}
+	if err := MkdirAll(pth, SETTINGS_DIR); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		f.Close()
+		return errwrap.Wrap(err, "Write %q", p)
+	}
+	return f.Close()
+}
+
+type InitOptions struct {
+	// GoVersion is resolved by GoVersions.Resolve and installed if missing.
+	// Defaults to the template Go version or go.default config.
+	GoVersion string
+	// Template is the template name.
+	Template string
+	// Stdout and Stderr receives the output of post-init commands.
+	Stdout, Stderr io.Writer
+}
+
+// InitWith inits the enviroment from options. The template and default Go
+// version are applied only to new enviroments. If it fails, the new
+// enviroment created from template is removed.
+func (env *GoEnv) InitWith(name string, options *InitOptions) (err error) {
+	env, unlock, err := env.LockEnv(false, name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	pth := filepath.Join(env.DbDir, name)
+	exists, err := IsFile(pth, "activate")
+	if err != nil {
+		return err
+	}
+
+	var (
+		config  = &TemplateConfig{}
+		created bool
+	)
+	// removes the partial enviroment created from template
+	defer func() {
+		if err != nil && created {
+			os.RemoveAll(pth)
+		}
+	}()
+	if options.Template != "" {
+		if exists {
+			return &EnvError{name, pth, ErrEnvExists}
+		}
+		t, err := env.Template(options.Template)
+		if err != nil {
+			return err
+		}
+		dir, cleanup, err := t.open(env)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		if config, err = readTemplateConfig(dir); err != nil {
+			return err
+		}
+		var vars []string
+		for key, value := range config.Env {
+			if err = checkEnvVar(key, value); err != nil {
+				return errwrap.Wrap(err, "Template %q", t.Name)
+			}
+			vars = append(vars, key+"="+value)
+		}
+		dirExists, err := IsDir(pth)
+		if err != nil {
+			return err
+		}
+		created = !dirExists
+		if err = copyTemplate(dir, pth); err != nil {
+			return errwrap.Wrap(err, "Copy template %q", t.Name)
+		}
+		sort.Strings(vars)
+		if err = appendSettings(pth, ENV_SETTINGS_NAME, vars...); err != nil {
+			return err
+		}
+		if err = appendSettings(pth, "backup_exclude", config.Exclude...); err != nil {
+			return err
+		}
+	}
+
+	if err = env.Init(name, ""); err != nil {
+		return err
+	}
+
+	version := options.GoVersion
+	if version == "" && !exists {
+		if version = config.Go; version == "" {
+			version = env.Config.String("go.default")
+		}
+	}
+	if version != "" {
+		vs := NewGoVersions(env)
+		if version, err = vs.Resolve(version, true); err != nil {
+			return err
+		}
+		if err = vs.Set(version, name); err != nil {
+			return fmt.Errorf("Set Go version %q: %w", version, err)
+		}
+	}
+
+	for _, command := range config.PostInit {
+		if err = env.run(name, command, options.Stdout, options.Stderr); err != nil {
+			return errwrap.Wrap(err, "Post init %q", command)
+		}
+	}
+	return nil
+}
+
+// copyTemplate copies the template dir to enviroment dir, without the
+// TEMPLATE_CONFIG_NAME file.
+func copyTemplate(dir, pth string) error {
+	items, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	if err = MkdirAll(pth); err != nil {
+		return err
+	}
+	for _, f := range items {
+		if f.Name() == TEMPLATE_CONFIG_NAME {
+			continue
+		}
+		if err = copyTree(context.Background(), filepath.Join(dir, f.Name()), filepath.Join(pth, f.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run runs the shell command into the activated enviroment.
+func (env *GoEnv) run(name, command string, stdout, stderr io.Writer) error {
+	pth, err := env.GetCheck(name)
+	if err != nil {
+		return err
+	}
+	dbDir, err := filepath.Abs(env.DbDir)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if stderr == nil {
+		stderr = &buf
+	}
+	cmd := exec.Command("bash", "-c", `goenv() { [ "$1" = db ] && echo "$GOENVROOT" || command goenv "$@"; }
+source ./activate && `+command)
+	locked, err := env.lockedEnv()
+	if err != nil {
+		return err
+	}
+	cmd.Dir = pth
+	cmd.Env = append(append(buildEnv(), "GOENVROOT="+dbDir), locked...)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err = cmd.Run(); err != nil && buf.Len() > 0 {
+		return errwrap.Wrap(err, strings.TrimSpace(buf.String()))
+	}
+	return err
+}
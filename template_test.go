@@ -0,0 +1,58 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadEnvSettingsInvalidName(t *testing.T) {
+	for _, line := range []string{"1FOO=x", "FOO BAR=x", "$(touch x)=y", "FOO-BAR=x"} {
+		dir := t.TempDir()
+		writeTestFile(t, filepath.Join(dir, SETTINGS_DIR, ENV_SETTINGS_NAME), line+"\n")
+		if _, err := readEnvSettings(dir); err == nil {
+			t.Errorf("line %q is accepted", line)
+		}
+	}
+}
+
+func TestInitWithTemplateInvalidEnv(t *testing.T) {
+	env := newTestEnv(t)
+	writeTestFile(t, filepath.Join(env.TemplatesDir(), "web", TEMPLATE_CONFIG_NAME), "[env]\n\"A;B\" = \"x\"\n")
+	if err := env.InitWith("e1", &InitOptions{Template: "web"}); err == nil {
+		t.Fatal("template with invalid variable name is accepted")
+	}
+	if _, err := os.Stat(filepath.Join(env.DbDir, "e1")); !os.IsNotExist(err) {
+		t.Errorf("enviroment dir is created: %v", err)
+	}
+}
+
+func TestInitWithTemplateCleanup(t *testing.T) {
+	env := newTestEnv(t)
+	dir := filepath.Join(env.TemplatesDir(), "web")
+	writeTestFile(t, filepath.Join(dir, TEMPLATE_CONFIG_NAME), "[env]\nFOO = \"x\"\n")
+	writeTestFile(t, filepath.Join(dir, "src", "main.go"), "package main\n")
+	// the settings dir can't be created
+	writeTestFile(t, filepath.Join(dir, SETTINGS_DIR), "")
+
+	if err := env.InitWith("e1", &InitOptions{Template: "web"}); err == nil {
+		t.Fatal("InitWith doesn't fails")
+	}
+	if _, err := os.Stat(filepath.Join(env.DbDir, "e1")); !os.IsNotExist(err) {
+		t.Errorf("partial enviroment isn't removed: %v", err)
+	}
+}
@@ -0,0 +1,344 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/moisespsena-go/error-wrap"
+)
+
+// TOOLS_SETTINGS_NAME is the file, in SETTINGS_DIR, of enviroment tools
+// manifest. Each line is "[NAME ]PACKAGE@VERSION".
+const TOOLS_SETTINGS_NAME = "tools"
+
+var majorVersionRe = regexp.MustCompile(`^v([2-9]|[1-9][0-9]+)$`)
+
+// Tool is a binary installed into $GOPATH/bin with `go install`.
+type Tool struct {
+	// Name is the binary name. Defaults to the last element of Package.
+	Name    string
+	Package string
+	// Version is the module version, as "v1.59.0" or "latest".
+	Version string
+}
+
+// ParseTool parses the manifest line "[NAME ]PACKAGE@VERSION". If VERSION is
+// omitted, it's "latest".
+func ParseTool(line string) (*Tool, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("Invalid tool %q.", line)
+	}
+	t := &Tool{Package: fields[len(fields)-1], Version: "latest"}
+	if i := strings.LastIndexByte(t.Package, '@'); i >= 0 {
+		t.Package, t.Version = t.Package[:i], t.Package[i+1:]
+	}
+	if t.Package == "" || t.Version == "" {
+		return nil, fmt.Errorf("Invalid tool %q.", line)
+	}
+	if len(fields) == 2 {
+		t.Name = fields[0]
+	} else {
+		t.Name = t.DefaultName()
+	}
+	if t.Name == "." || t.Name == ".." || strings.ContainsAny(t.Name, `/\`) {
+		return nil, fmt.Errorf("Invalid tool name %q.", t.Name)
+	}
+	return t, nil
+}
+
+// DefaultName returns the binary name built by `go install`.
+func (t *Tool) DefaultName() string {
+	name := path.Base(t.Package)
+	if majorVersionRe.MatchString(name) && path.Dir(t.Package) != "." {
+		name = path.Base(path.Dir(t.Package))
+	}
+	return name
+}
+
+// String returns the manifest line.
+func (t *Tool) String() string {
+	s := t.Package + "@" + t.Version
+	if t.Name != t.DefaultName() {
+		s = t.Name + " " + s
+	}
+	return s
+}
+
+// Tools returns the tools manifest of enviroment.
+func (env *GoEnv) Tools(name string) (tools []*Tool, err error) {
+	pth, err := env.GetCheck(name)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := readSettings(pth, TOOLS_SETTINGS_NAME)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		t, err := ParseTool(line)
+		if err != nil {
+			return nil, errwrap.Wrap(err, "Enviroment %q", name)
+		}
+		tools = append(tools, t)
+	}
+	return
+}
+
+func (env *GoEnv) writeTools(name string, tools []*Tool) error {
+	pth, err := env.GetCheck(name)
+	if err != nil {
+		return err
+	}
+	lines := make([]string, len(tools))
+	for i, t := range tools {
+		lines[i] = t.String()
+	}
+	return writeSettings(pth, TOOLS_SETTINGS_NAME, lines...)
+}
+
+// AddTool records the tool into manifest of enviroment, replacing the tool
+// with same name.
+func (env *GoEnv) AddTool(name string, tool *Tool) error {
+	env, unlock, err := env.LockEnv(false, name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tools, err := env.Tools(name)
+	if err != nil {
+		return err
+	}
+	for i, t := range tools {
+		if t.Name == tool.Name {
+			tools[i] = tool
+			return env.writeTools(name, tools)
+		}
+	}
+	return env.writeTools(name, append(tools, tool))
+}
+
+// RmTool removes the tools from manifest of enviroment. The binaries are
+// kept.
+func (env *GoEnv) RmTool(name string, toolNames ...string) error {
+	env, unlock, err := env.LockEnv(false, name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tools, err := env.Tools(name)
+	if err != nil {
+		return err
+	}
+	var result []*Tool
+main:
+	for _, t := range tools {
+		for _, toolName := range toolNames {
+			if t.Name == toolName {
+				continue main
+			}
+		}
+		result = append(result, t)
+	}
+	if len(result) == len(tools) {
+		return fmt.Errorf("Tools %q not found.", toolNames)
+	}
+	return env.writeTools(name, result)
+}
+
+// ToolStatus is the state of declared tool binary.
+type ToolStatus struct {
+	*Tool
+	// Path is the binary path.
+	Path string
+	// Installed is the module version read from binary build info. It's
+	// empty if the binary doesn't exists.
+	Installed string
+	// Module is the main module path read from binary build info.
+	Module string
+	// GoVersion is the toolchain version that built the binary.
+	GoVersion string
+	// Err is the error reading the binary, which isn't a Go binary or isn't
+	// readable. The tool is reported as not installed.
+	Err error
+}
+
+// Ok reports whether the binary is installed with the declared version. For
+// "latest", any installed version is accepted.
+func (s *ToolStatus) Ok() bool {
+	if s.Installed == "" {
+		return false
+	}
+	return s.Version == "latest" || s.Version == s.Installed
+}
+
+// ToolsStatus returns the state of tools declared into manifest of
+// enviroment.
+func (env *GoEnv) ToolsStatus(name string) (status []*ToolStatus, err error) {
+	env, unlock, err := env.LockEnv(true, name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	pth, err := env.GetCheck(name)
+	if err != nil {
+		return nil, err
+	}
+	tools, err := env.Tools(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tools {
+		s := &ToolStatus{Tool: t, Path: filepath.Join(pth, "bin", t.Name)}
+		status = append(status, s)
+		if ok, err := IsFile(s.Path); err != nil {
+			s.Err = err
+			continue
+		} else if !ok {
+			continue
+		}
+		info, err := buildinfo.ReadFile(s.Path)
+		if err != nil {
+			s.Err = err
+			continue
+		}
+		s.Installed, s.Module, s.GoVersion = info.Main.Version, info.Main.Path, info.GoVersion
+	}
+	return
+}
+
+// InstallTool installs the tool into $GOPATH/bin of enviroment with
+// `go install` of bound toolchain. The binary is built into temporary GOBIN
+// and moved to Name, so tools of same package with other names aren't
+// overwritten.
+func (env *GoEnv) InstallTool(name string, tool *Tool, stdout, stderr io.Writer) error {
+	env, unlock, err := env.LockEnv(false, name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	pth, err := env.GetCheck(name)
+	if err != nil {
+		return err
+	}
+	tmpDir, err := env.TempDir()
+	if err != nil {
+		return err
+	}
+	gobin, err := ioutil.TempDir(tmpDir, "tool-"+tool.Name+"-")
+	if err != nil {
+		return errwrap.Wrap(err, "Create GOBIN")
+	}
+	defer os.RemoveAll(gobin)
+	if gobin, err = filepath.Abs(gobin); err != nil {
+		return err
+	}
+	command := "GOBIN=" + shellQuote(gobin) + " go install " + shellQuote(tool.Package+"@"+tool.Version)
+	if err = env.run(name, command, stdout, stderr); err != nil {
+		return errwrap.Wrap(err, "Install %q", tool.String())
+	}
+	bin := filepath.Join(pth, "bin")
+	if err = MkdirAll(bin); err != nil {
+		return err
+	}
+	if err = os.Rename(filepath.Join(gobin, tool.DefaultName()), filepath.Join(bin, tool.Name)); err != nil {
+		return errwrap.Wrap(err, "Move %q binary", tool.Name)
+	}
+	return nil
+}
+
+// SyncTools installs the declared tools missing or with other version than
+// installed. If force is true, installs all tools.
+func (env *GoEnv) SyncTools(name string, force bool, stdout, stderr io.Writer) (installed []*Tool, err error) {
+	env, unlock, err := env.LockEnv(false, name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	status, err := env.ToolsStatus(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range status {
+		if s.Ok() && !force {
+			continue
+		}
+		if err = env.InstallTool(name, s.Tool, stdout, stderr); err != nil {
+			return
+		}
+		installed = append(installed, s.Tool)
+	}
+	return
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// shellUnquote returns the value of shell word quoted by shellQuote, or of
+// double quoted word. The variables aren't expanded.
+func shellUnquote(word string) (string, error) {
+	var (
+		b     strings.Builder
+		quote rune
+		esc   bool
+	)
+	for _, r := range word {
+		switch {
+		case esc:
+			if quote == '"' && !strings.ContainsRune("\"$`\\", r) {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(r)
+			esc = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				b.WriteRune(r)
+			}
+		case r == '\\':
+			esc = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				b.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if esc || quote != 0 {
+		return "", fmt.Errorf("Unterminated shell word %q.", word)
+	}
+	return b.String(), nil
+}
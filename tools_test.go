@@ -0,0 +1,114 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTool(t *testing.T) {
+	for _, test := range []struct {
+		line string
+		want Tool
+	}{
+		{"golang.org/x/tools/cmd/goimports@v0.20.0", Tool{"goimports", "golang.org/x/tools/cmd/goimports", "v0.20.0"}},
+		{"golang.org/x/tools/cmd/goimports", Tool{"goimports", "golang.org/x/tools/cmd/goimports", "latest"}},
+		{"gi golang.org/x/tools/cmd/goimports@latest", Tool{"gi", "golang.org/x/tools/cmd/goimports", "latest"}},
+		{"  lint   github.com/golangci/golangci-lint/cmd/golangci-lint@v1.59.0 ", Tool{"lint", "github.com/golangci/golangci-lint/cmd/golangci-lint", "v1.59.0"}},
+		{"example.com/tool/v2@v2.1.0", Tool{"tool", "example.com/tool/v2", "v2.1.0"}},
+		{"example.com/tool/v10@v10.0.1", Tool{"tool", "example.com/tool/v10", "v10.0.1"}},
+		{"example.com/cmd/v1@v0.1.0", Tool{"v1", "example.com/cmd/v1", "v0.1.0"}},
+		{"example.com/cmd/v01@v0.1.0", Tool{"v01", "example.com/cmd/v01", "v0.1.0"}},
+		{"v2@v2.0.0", Tool{"v2", "v2", "v2.0.0"}},
+		{"example.com/tool@v1.0.0-rc.1+meta", Tool{"tool", "example.com/tool", "v1.0.0-rc.1+meta"}},
+	} {
+		tool, err := ParseTool(test.line)
+		if err != nil {
+			t.Errorf("%q: %v", test.line, err)
+			continue
+		}
+		if *tool != test.want {
+			t.Errorf("%q: parsed %+v, want %+v", test.line, *tool, test.want)
+		}
+
+		// the manifest line is parsed to same tool
+		again, err := ParseTool(tool.String())
+		if err != nil {
+			t.Errorf("%q: parse of %q: %v", test.line, tool.String(), err)
+		} else if *again != *tool {
+			t.Errorf("%q: %q is parsed as %+v", test.line, tool.String(), *again)
+		}
+	}
+}
+
+func TestParseToolInvalid(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"   ",
+		"a b c",
+		"example.com/tool@",
+		"@v1.0.0",
+		"x/y example.com/tool",
+		`x\y example.com/tool`,
+		".. example.com/tool",
+		". example.com/tool",
+	} {
+		if tool, err := ParseTool(line); err == nil {
+			t.Errorf("%q is parsed as %+v", line, *tool)
+		}
+	}
+}
+
+func TestToolString(t *testing.T) {
+	for tool, want := range map[Tool]string{
+		{"goimports", "golang.org/x/tools/cmd/goimports", "v0.20.0"}: "golang.org/x/tools/cmd/goimports@v0.20.0",
+		{"gi", "golang.org/x/tools/cmd/goimports", "latest"}:         "gi golang.org/x/tools/cmd/goimports@latest",
+		{"tool", "example.com/tool/v3", "v3.0.0"}:                    "example.com/tool/v3@v3.0.0",
+		{"v3", "example.com/tool/v3", "v3.0.0"}:                      "v3 example.com/tool/v3@v3.0.0",
+	} {
+		if s := tool.String(); s != want {
+			t.Errorf("%+v: %q, want %q", tool, s, want)
+		}
+	}
+}
+
+func TestToolsStatusInvalidBinary(t *testing.T) {
+	env := newTestEnv(t, "e1")
+	for _, line := range []string{"example.com/cmd/broken@v1.0.0", "example.com/cmd/missing@v1.0.0"} {
+		tool, err := ParseTool(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = env.AddTool("e1", tool); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTestFile(t, filepath.Join(env.DbDir, "e1", "bin", "broken"), "#!/bin/sh\n")
+
+	status, err := env.ToolsStatus("e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 2 {
+		t.Fatalf("%d status, want 2", len(status))
+	}
+	if s := status[0]; s.Err == nil || s.Installed != "" || s.Ok() {
+		t.Errorf("broken binary: %+v", *s)
+	}
+	if s := status[1]; s.Err != nil || s.Installed != "" || s.Ok() {
+		t.Errorf("missing binary: %+v", *s)
+	}
+}
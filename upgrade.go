@@ -0,0 +1,138 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// UpgradePlan is the version upgrade of enviroment. If To is empty, the
+// enviroment isn't upgraded and Reason tells why.
+type UpgradePlan struct {
+	Env  string
+	From string
+	To   string
+	// Install is true if To isn't installed.
+	Install bool
+	Reason  string
+}
+
+type UpgradeOptions struct {
+	// Minor allows upgrade to newer minor lines of same major.
+	Minor bool
+	// Offline uses only the installed versions.
+	Offline bool
+}
+
+// PlanUpgrade returns the upgrade plans of enviroments to the newest patch,
+// installed or available for download, of its current minor line.
+func (vs *GoVersions) PlanUpgrade(options *UpgradeOptions, envNames ...string) (plans []*UpgradePlan, err error) {
+	return vs.PlanUpgradeContext(context.Background(), options, envNames...)
+}
+
+// PlanUpgradeContext is like PlanUpgrade, but the request of available
+// versions is canceled when ctx is done.
+func (vs *GoVersions) PlanUpgradeContext(ctx context.Context, options *UpgradeOptions, envNames ...string) (plans []*UpgradePlan, err error) {
+	installed, err := vs.Ls()
+	if err != nil {
+		return nil, err
+	}
+	var available []*GoVersion
+	if !options.Offline {
+		if available, err = vs.AvailableContext(ctx, false); err != nil {
+			return nil, fmt.Errorf("Get available versions: %w", err)
+		}
+	}
+
+	candidates := map[string]bool{}
+	for _, v := range available {
+		candidates[v.Name] = false
+	}
+	for _, v := range installed {
+		candidates[v.Name] = true
+	}
+
+	for _, envName := range envNames {
+		b, err := vs.Env.GoVersionBinding(envName)
+		if err != nil {
+			return nil, fmt.Errorf("Go version of %q: %w", envName, err)
+		}
+		plan := &UpgradePlan{Env: envName, From: b.Name()}
+		plans = append(plans, plan)
+		if b.IsSystem() {
+			plan.Reason = "uses system Go"
+			continue
+		}
+		if !isGoRelease(b.Version) {
+			plan.Reason = "not a release version"
+			continue
+		}
+		current, _ := parseGoVersion(b.Version)
+		best := b.Version
+		for name := range candidates {
+			v, _ := parseGoVersion(name)
+			if !isGoRelease(name) || v[0] != current[0] || (!options.Minor && v[1] != current[1]) {
+				continue
+			}
+			if compareGoVersion(best, name) < 0 {
+				best = name
+			}
+		}
+		if best == b.Version {
+			plan.Reason = "up to date"
+			continue
+		}
+		plan.To = best
+		plan.Install = !candidates[plan.To]
+	}
+	return
+}
+
+// Upgrade installs the missing versions of plans and binds them to the
+// enviroments.
+func (vs *GoVersions) Upgrade(plans ...*UpgradePlan) error {
+	return vs.UpgradeContext(context.Background(), plans...)
+}
+
+// UpgradeContext is like Upgrade, but the install of versions is canceled when
+// ctx is done.
+func (vs *GoVersions) UpgradeContext(ctx context.Context, plans ...*UpgradePlan) error {
+	var (
+		names []string
+		seen  = map[string]bool{}
+	)
+	for _, plan := range plans {
+		if plan.To != "" && plan.Install && !seen[plan.To] {
+			seen[plan.To] = true
+			names = append(names, strings.TrimPrefix(plan.To, "go"))
+		}
+	}
+	if len(names) > 0 {
+		if _, err := vs.InstallContext(ctx, names...); err != nil {
+			return fmt.Errorf("Install versions: %w", err)
+		}
+	}
+	for _, plan := range plans {
+		if plan.To == "" {
+			continue
+		}
+		if err := vs.Set(plan.To, plan.Env); err != nil {
+			return fmt.Errorf("Set version %q to %q: %w", plan.To, plan.Env, err)
+		}
+	}
+	return nil
+}
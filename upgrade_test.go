@@ -0,0 +1,57 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"testing"
+)
+
+func TestPlanUpgrade(t *testing.T) {
+	vs := NewGoVersions(newTestEnv(t, "e1", "e2", "e3", "e4"))
+	for _, name := range []string{"go1.21.3", "go1.21.5", "go1.21.10-custom", "go1.22.1", "go1.23rc1", "go2.0.0"} {
+		if _, err := vs.Add(name, newTestGoRoot(t, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for env, version := range map[string]string{"e1": "go1.21.3", "e2": "go1.22.1", "e4": "go1.23rc1"} {
+		if err := vs.Set(version, env); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, minor := range []bool{false, true} {
+		plans, err := vs.PlanUpgrade(&UpgradeOptions{Minor: minor, Offline: true}, "e1", "e2", "e3", "e4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []UpgradePlan{
+			{Env: "e1", From: "go1.21.3", To: "go1.21.5"},
+			{Env: "e2", From: "go1.22.1", Reason: "up to date"},
+			{Env: "e3", From: SYS_VERSION, Reason: "uses system Go"},
+			{Env: "e4", From: "go1.23rc1", Reason: "not a release version"},
+		}
+		if minor {
+			want[0].To = "go1.22.1"
+		}
+		if len(plans) != len(want) {
+			t.Fatalf("minor %v: %d plans, want %d", minor, len(plans), len(want))
+		}
+		for i, plan := range plans {
+			if *plan != want[i] {
+				t.Errorf("minor %v: plan %+v, want %+v", minor, *plan, want[i])
+			}
+		}
+	}
+}
@@ -32,7 +32,7 @@ func IsDir(pth ...string) (bool, error) {
 		if os.IsNotExist(err) {
 			return false, nil
 		}
-		return false, fmt.Errorf("'%v': %v", p, err)
+		return false, fmt.Errorf("'%v': %w", p, err)
 	}
 	if !s.IsDir() {
 		return false, fmt.Errorf("'%v': Isn't directory.", p)
@@ -47,7 +47,7 @@ func IsFile(pth ...string) (bool, error) {
 		if os.IsNotExist(err) {
 			return false, nil
 		}
-		return false, fmt.Errorf("'%v': %v", p, err)
+		return false, fmt.Errorf("'%v': %w", p, err)
 	}
 	if s.IsDir() {
 		return false, fmt.Errorf("'%v': Is directory.", p)
@@ -72,15 +72,14 @@ func MkdirAll(pth ...string) error {
 				parent = filepath.Dir(parent)
 				continue
 			}
-			return fmt.Errorf("'%v': %v", parent, err)
+			return fmt.Errorf("'%v': %w", parent, err)
 		}
 		mode = stat.Mode()
 		err = os.MkdirAll(p, mode)
 		if err != nil {
-			return fmt.Errorf("'%v': %v", p, err)
+			return fmt.Errorf("'%v': %w", p, err)
 		}
 		return nil
-		break
 	}
 	return fmt.Errorf("'%v': Invalid path.", p)
 }
@@ -105,8 +104,8 @@ func readLines(pth string) ([]string, error) {
 	for err == nil {
 		i++
 		line, err = iolr.ReadLine(f)
-		if err == nil {
-			if line[len(line)-1] == '\r' {
+		if err == nil || (err == io.EOF && len(line) > 0) {
+			if len(line) > 0 && line[len(line)-1] == '\r' {
 				line = line[0 : len(line)-1]
 			}
 			lines = append(lines, strings.TrimSpace(string(line)))
@@ -122,13 +121,11 @@ const activateData = `
 export GOPATH="$GOENVROOT/$GOENVNAME"
 export OLDPS1=$PS1
 export PS1="[go:$GOENVNAME] $PS1"
-export OLDPATH="$PATH"
 export PATH="$GOPATH/bin:$PATH"
 alias gcd="cd $GOPATH"
 goenv-deactivate() {
 	export PS1=$OLDPS1
 	export PATH=$OLDPATH
-	unset GOPATH
 	unset OLDPS1
 	unset OLDPATH
 	unalias gcd
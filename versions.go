@@ -16,6 +16,7 @@ package goenv
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"os/exec"
@@ -24,6 +25,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
@@ -35,7 +37,6 @@ import (
 	"path/filepath"
 
 	"github.com/cavaliercoder/grab"
-	"github.com/dustin/go-humanize"
 	errwrap "github.com/moisespsena-go/error-wrap"
 )
 
@@ -54,6 +55,8 @@ func NewGoBinVersion(binName string) (*GoBinVersion, error) {
 		return errwrap.Wrap(errwrap.Wrap(child, self, args...), "GoBinVersion of %q", binName)
 	}
 	cmd := exec.Command(binName, "version")
+	// reports the binary version instead of the toolchain selected by go.mod
+	cmd.Env = append(os.Environ(), "GOTOOLCHAIN=local")
 	var out bytes.Buffer
 	cmd.Stdout = &out
 	err := cmd.Start()
@@ -86,11 +89,15 @@ func GetSystemGoVersion() (*GoVersion, error) {
 				}
 				return nil, errwrap.Wrap(err, "Get Stat of %q", pth)
 			}
-			absPath, err := filepath.Abs(p)
+			// the go binary is commonly linked as /usr/bin/go
+			realPath, err := filepath.EvalSymlinks(pth)
 			if err != nil {
-				return nil, errwrap.Wrap(err, "Get Abs Path of %q", p)
+				return nil, errwrap.Wrap(err, "Resolve links of %q", pth)
 			}
-			goroot = filepath.Dir(absPath)
+			if realPath, err = filepath.Abs(realPath); err != nil {
+				return nil, errwrap.Wrap(err, "Get Abs Path of %q", realPath)
+			}
+			goroot = filepath.Dir(filepath.Dir(realPath))
 			break
 		}
 	}
@@ -106,16 +113,19 @@ func GetSystemGoVersion() (*GoVersion, error) {
 }
 
 type GoVersion struct {
-	versions     *GoVersions
-	ID           string
-	Name         string
-	UpdatedAt    time.Time
-	downloadUrl  string
-	Installed    bool
-	Root         string
-	System       bool
-	BinVersion   *GoBinVersion
+	versions    *GoVersions
+	ID          string
+	Name        string
+	UpdatedAt   time.Time
+	downloadUrl string
+	Installed   bool
+	Root        string
+	System      bool
+	BinVersion  *GoBinVersion
+	// LinkTarget is the GOROOT of external toolchain registered by Add.
+	LinkTarget   string
 	downloadPath string
+	fileName     string
 }
 
 func NewGoVersion(goroot string) (v *GoVersion, err error) {
@@ -126,19 +136,25 @@ func NewGoVersion(goroot string) (v *GoVersion, err error) {
 	}
 	return &GoVersion{Root: goroot, Name: binVersion.Version, BinVersion: binVersion}, nil
 }
+func (v *GoVersion) FileName() string {
+	if v.fileName == "" {
+		v.fileName = filepath.Base(v.DownloadUrl())
+	}
+	return v.fileName
+}
 func (v *GoVersion) DownloadPath() string {
 	if v.downloadPath == "" {
-		v.downloadPath = filepath.Join(v.versions.Dir(), filepath.Base(v.DownloadUrl()))
+		v.downloadPath = filepath.Join(v.versions.Dir(), v.FileName())
 	}
 	return v.downloadPath
 }
 func (v *GoVersion) Downloadable(client *http.Client) (bool, error) {
 	r, err := client.Head(v.DownloadUrl())
-	defer r.Body.Close()
-	if err == nil && r.StatusCode == 200 {
-		return true, nil
+	if err != nil {
+		return false, errwrap.Wrap(err, "HTTP HEAD %q", v.downloadUrl)
 	}
-	return false, errwrap.Wrap(err, "HTTP HEAD %q", v.downloadUrl)
+	defer r.Body.Close()
+	return r.StatusCode == 200, nil
 }
 
 func (v *GoVersion) DownloadUrl() string {
@@ -147,10 +163,32 @@ func (v *GoVersion) DownloadUrl() string {
 
 type GoVersions struct {
 	Env *GoEnv
+
+	// Mirrors are the base URLs tried, in order, before the golang.org
+	// download URL.
+	Mirrors []string
+	// Retries is the number of download retries after all URLs fails.
+	Retries int
+	// RetryBackoff is the wait before the first retry, doubled on each retry.
+	RetryBackoff time.Duration
+	// KeepArchives doesn't removes the downloaded archive after install.
+	KeepArchives bool
+	// Logger receives the download and install events. If nil, the Logger
+	// of Env is used.
+	Logger Logger
 }
 
-func NewGoVersions(env *GoEnv) *GoVersions {
-	return &GoVersions{env}
+func NewGoVersions(env *GoEnv, opts ...VersionsOption) *GoVersions {
+	vs := &GoVersions{
+		Env:          env,
+		Mirrors:      env.Config.Strings("versions.mirrors"),
+		Retries:      3,
+		RetryBackoff: 2 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(vs)
+	}
+	return vs
 }
 
 func (v *GoVersions) Dir() string {
@@ -182,16 +220,28 @@ func (v *GoVersions) Ls() (vs []*GoVersion, err error) {
 		if err != nil {
 			return nil, errwrap.Wrap(err, "Open %q", dir)
 		}
+		defer s.Close()
 		items, err := s.Readdir(-1)
 		if err != nil {
 			return nil, errwrap.Wrap(err, "Readdir %q", dir)
 		}
 		for _, f := range items {
+			pth := filepath.Join(dir, f.Name())
+			var linkTarget string
+			if f.Mode()&os.ModeSymlink != 0 {
+				if linkTarget, err = os.Readlink(pth); err != nil {
+					return nil, errwrap.Wrap(err, "Read link %q", pth)
+				}
+				if f, err = os.Stat(pth); err != nil {
+					// broken link
+					continue
+				}
+			}
 			if f.IsDir() {
-				pth := filepath.Join(dir, f.Name())
 				version, err := NewGoVersion(pth)
 				if version != nil {
-					version.Name = f.Name()
+					version.Name = filepath.Base(pth)
+					version.LinkTarget = linkTarget
 					version.versions = v
 					if err != nil {
 						return nil, errwrap.Wrap(err, "Parse Version of %q", pth)
@@ -204,43 +254,258 @@ func (v *GoVersions) Ls() (vs []*GoVersion, err error) {
 	return
 }
 
-func (v *GoVersions) Set(versionName, envName string) (err error) {
-	system, err := GetSystemGoVersion()
+// Get returns the installed version with name, or nil if it isn't installed.
+func (v *GoVersions) Get(name string) (*GoVersion, error) {
+	versions, err := v.Ls()
 	if err != nil {
-		return err
+		return nil, errwrap.Wrap(err, "Get installed versions")
 	}
-	if system == nil {
-		return errwrap.Wrap(err, "GO isn't available on system. Please install it from https://golang.org/dl")
+	for _, ver := range versions {
+		if ver.Name == name {
+			return ver, nil
+		}
 	}
+	return nil, nil
+}
 
-	if versionName == "sys" {
-		err = v.Env.SetGoVersion(versionName, "")
-	} else {
-		versionName = strings.ToLower(versionName)
-		versions, err := v.Ls()
-		if err != nil {
-			return errwrap.Wrap(err, "Get installed versions")
+// Resolve returns the installed version name of version, as "go1.22.3",
+// "1.22.3", "1.22" or "sys". The minor line (as "1.22") resolves to the newest
+// installed release. If install is true and the version isn't installed, the
+// version (or the newest available release of minor line) is installed.
+func (v *GoVersions) Resolve(version string, install bool) (string, error) {
+	version = strings.ToLower(version)
+	switch version {
+	case SYS_VERSION, SYS_PIN_VERSION:
+		return version, nil
+	}
+	installed, err := v.Ls()
+	if err != nil {
+		return "", err
+	}
+	name := "go" + strings.TrimPrefix(version, "go")
+	for _, ver := range installed {
+		if ver.Name == version || ver.Name == name {
+			return ver.Name, nil
 		}
+	}
 
-		var version *GoVersion
-
+	// minor line, as "go1.22"
+	line, _ := parseGoVersion(name)
+	isLine := isGoRelease(name) && line[2] == -1
+	newest := func(versions []*GoVersion) (result string) {
 		for _, ver := range versions {
-			if ver.Name == versionName {
-				version = ver
-				break
+			if isGoRelease(ver.Name) && strings.HasPrefix(ver.Name, name) && (result == "" || compareGoVersion(result, ver.Name) < 0) &&
+				(len(ver.Name) == len(name) || ver.Name[len(name)] == '.') {
+				result = ver.Name
 			}
 		}
+		return
+	}
+	if isLine {
+		if result := newest(installed); result != "" {
+			return result, nil
+		}
+	}
+
+	if !install {
+		return "", &VersionError{version, ErrVersionNotInstalled}
+	}
+	if isLine {
+		available, err := v.Available(false, name[2:], name[2:]+".*")
+		if err != nil {
+			return "", err
+		}
+		if name = newest(available); name == "" {
+			return "", &VersionError{version, ErrVersionNotAvailable}
+		}
+	}
+	versions, err := v.Install(name[2:])
+	if err != nil {
+		return "", err
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("GoLang version %q isn't available.", name)
+	}
+	return versions[0].Name, nil
+}
+
+// Add registers the external toolchain of goroot (or of go binary) as version
+// name. The toolchain is linked into versions directory.
+func (v *GoVersions) Add(name, goroot string) (version *GoVersion, err error) {
+	v, unlock, err := v.Lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	name = strings.ToLower(name)
+	if name == "" || name == "sys" || strings.ContainsAny(name, `/\`) || name[0] == '.' {
+		return nil, fmt.Errorf("Invalid version name %q.", name)
+	}
+	if goroot, err = filepath.Abs(goroot); err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(goroot); err != nil {
+		return nil, errwrap.Wrap(err, "Stat of %q", goroot)
+	} else if !info.IsDir() {
+		// the go binary: GOROOT/bin/go, commonly linked as /usr/bin/go
+		if goroot, err = filepath.EvalSymlinks(goroot); err != nil {
+			return nil, errwrap.Wrap(err, "Resolve links of %q", goroot)
+		}
+		goroot = filepath.Dir(filepath.Dir(goroot))
+	}
+	if version, err = NewGoVersion(goroot); err != nil {
+		return nil, errwrap.Wrap(err, "%q isn't GoLang root", goroot)
+	}
+
+	dir, exists, err := v.DirExists()
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err = os.MkdirAll(dir, 0777); err != nil {
+			return nil, errwrap.Wrap(err, "Create versions directory.")
+		}
+	}
+	pth := filepath.Join(dir, name)
+	if _, err = os.Lstat(pth); err == nil {
+		return nil, fmt.Errorf("GoLang version %q has be installed.", name)
+	}
+	if err = os.Symlink(goroot, pth); err != nil {
+		return nil, errwrap.Wrap(err, "Link %q to %q", pth, goroot)
+	}
+	version.Name = name
+	version.Root = pth
+	version.LinkTarget = goroot
+	version.versions = v
+	return version, nil
+}
+
+// Rm removes the installed version. If it is an external toolchain, only
+// unregister it. If the version is used by any enviroment, returns
+// *VersionError of ErrVersionInUse, unless force.
+func (v *GoVersions) Rm(name string, force bool) (err error) {
+	v, unlock, err := v.Lock(false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	name = strings.ToLower(name)
+	if name == "" || name == "sys" || strings.ContainsAny(name, `/\`) || name[0] == '.' {
+		return fmt.Errorf("Invalid version name %q.", name)
+	}
+	pth := filepath.Join(v.Dir(), name)
+	info, err := os.Lstat(pth)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &VersionError{name, ErrVersionNotInstalled}
+		}
+		return errwrap.Wrap(err, "Stat of %q", pth)
+	}
+	if !force {
+		usage, err := v.Usage()
+		if err != nil {
+			return err
+		}
+		if envs := usage[name]; len(envs) > 0 {
+			return fmt.Errorf("%w Used by: %s.", &VersionError{name, ErrVersionInUse}, strings.Join(envs, ", "))
+		}
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return os.Remove(pth)
+	}
+	return os.RemoveAll(pth)
+}
+
+// Set binds the version to enviroment. The versionName is an installed
+// version, "sys" to use the go command found on PATH, or "sys:pin" to use the
+// current system GOROOT.
+// If the enviroment doesn't exists, returns *EnvError, and if the version
+// isn't installed, *VersionError of ErrVersionNotInstalled.
+func (v *GoVersions) Set(versionName, envName string) (err error) {
+	var (
+		binding = &GoVersionBinding{Version: SYS_VERSION}
+		version *GoVersion
+	)
+	// the enviroment lock is ever acquired before the versions lock
+	env, unlock, err := v.Env.LockEnv(false, envName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, err = env.GetCheck(envName); err != nil {
+		return err
+	}
+	v, unlockVersions, err := v.withEnv(env).Lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlockVersions()
+	versionName = strings.ToLower(versionName)
 
+	switch versionName {
+	case SYS_VERSION, SYS_PIN_VERSION:
+		if version, err = GetSystemGoVersion(); err != nil {
+			return err
+		}
+		if version == nil {
+			return fmt.Errorf("GO isn't available on system. Please install it from https://golang.org/dl")
+		}
+		binding.Pin = versionName == SYS_PIN_VERSION
+		if binding.Root, err = filepath.Abs(version.Root); err != nil {
+			return err
+		}
+	default:
+		if version, err = v.Get(versionName); err != nil {
+			return err
+		}
 		if version == nil {
-			return fmt.Errorf("GoLang version %q has not be installed", versionName)
+			return &VersionError{versionName, ErrVersionNotInstalled}
 		}
+		binding.Version = version.Name
+		binding.Root = filepath.Join("$GOENVROOT", VERSIONS_BASENAME, version.Name)
+	}
+	binding.GoVersion = version.BinVersion.Version
+	binding.OsInfo = version.BinVersion.OsInfo
 
-		err = v.Env.SetGoVersion(envName, filepath.Join("$GOENVROOT", VERSIONS_BASENAME, version.Name))
+	if err = v.Env.BindGoVersion(envName, binding); err != nil {
+		return fmt.Errorf("Env Set Go Version: %w", err)
 	}
-	return errwrap.Wrap(err, "Env Set Go Version")
+	return nil
+}
+
+// Usage returns the enviroments names by version name (see
+// GoVersionBinding.Name).
+func (v *GoVersions) Usage() (usage map[string][]string, err error) {
+	names, err := v.Env.Ls()
+	if err != nil {
+		return nil, err
+	}
+	usage = map[string][]string{}
+	for _, name := range names {
+		b, err := v.Env.GoVersionBinding(name)
+		if err != nil {
+			return nil, fmt.Errorf("Go version of %q: %w", name, err)
+		}
+		usage[b.Name()] = append(usage[b.Name()], name)
+	}
+	return
 }
 
 func (v *GoVersions) Download(names ...string) (versions []*GoVersion, err error) {
+	return v.DownloadContext(context.Background(), names...)
+}
+
+// DownloadContext is like Download, but the downloads are canceled when ctx is
+// done. The partial archives of canceled downloads are removed.
+func (v *GoVersions) DownloadContext(ctx context.Context, names ...string) (versions []*GoVersion, err error) {
+	v, unlock, err := v.Lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	if len(names) == 0 {
 		return
 	}
@@ -254,9 +519,16 @@ func (v *GoVersions) Download(names ...string) (versions []*GoVersion, err error
 		return nil, err
 	}
 
-	versions, err = v.Available(false, names...)
+	versions, err = v.AvailableContext(ctx, false, names...)
 	if err != nil {
-		return nil, err
+		if len(v.Mirrors) == 0 || ctx.Err() != nil {
+			return nil, err
+		}
+		// golang.org may be unreachable where mirrors are used.
+		v.event(&Event{Type: EventAvailableFailed, Err: err})
+		if versions, err = v.mirrorVersions(names...); err != nil {
+			return nil, err
+		}
 	}
 
 	if !exists {
@@ -269,92 +541,212 @@ func (v *GoVersions) Download(names ...string) (versions []*GoVersion, err error
 	// create client
 	client := grab.NewClient()
 
-	l := len(versions)
-	resp := make([]*grab.Response, len(versions))
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		dok []*GoVersion
+	)
+
+	for _, ver := range versions {
+		ver.Root = filepath.Join(dir, ver.Name)
+		wg.Add(1)
+		go func(ver *GoVersion) {
+			defer wg.Done()
+			if err := v.download(ctx, client, ver); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				v.event(&Event{Type: EventDownloadFailed, Version: ver.Name, Err: err})
+				return
+			}
+			mu.Lock()
+			dok = append(dok, ver)
+			mu.Unlock()
+		}(ver)
+	}
+	wg.Wait()
 
-	hb := func(value int64) string {
-		return humanize.Bytes(uint64(value)) + " (" + strconv.Itoa(int(value)) + " bytes)"
+	if err = ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	for i, v := range versions {
-		v.Root = filepath.Join(dir, v.Name)
-		req, _ := grab.NewRequest(v.DownloadPath(), v.DownloadUrl())
-		fmt.Printf("[%v] Downloading %v... ", v.Name, req.URL())
-		resp[i] = client.Do(req)
-		fmt.Printf("[HTTP %v]", resp[i].HTTPResponse.Status)
-		switch resp[i].HTTPResponse.StatusCode {
-		case 200, 206:
-			fmt.Printf(" Left Size: %v", hb(resp[i].HTTPResponse.ContentLength))
-		}
-		fmt.Println()
+	sort.Slice(dok, func(i, j int) bool {
+		return dok[i].ID < dok[j].ID
+	})
+	return dok, nil
+}
+
+// DownloadUrls returns the download URL on each mirror, followed by the
+// original download URL.
+func (v *GoVersion) DownloadUrls(mirrors ...string) (urls []string) {
+	fileName := v.FileName()
+	for _, mirror := range mirrors {
+		urls = append(urls, strings.TrimSuffix(mirror, "/")+"/"+fileName)
 	}
+	if v.downloadUrl != "" {
+		urls = append(urls, v.downloadUrl)
+	}
+	return
+}
 
-	var dok []*GoVersion
-	t := time.NewTicker(2 * time.Second)
-	defer t.Stop()
+// download downloads the version trying each URL of DownloadUrls. If all URLs
+// fails, retries after RetryBackoff, doubled on each attempt. The partial
+// downloads are resumed, unless ctx is done: then the partial download is
+// removed.
+func (v *GoVersions) download(ctx context.Context, client *grab.Client, ver *GoVersion) (err error) {
+	defer func() {
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+			if err2 := os.Remove(ver.DownloadPath()); err2 != nil && !os.IsNotExist(err2) {
+				err = errwrap.Wrap(err2, "Remove partial download %q", ver.DownloadPath())
+			}
+		}
+	}()
 
-	for l > 0 {
-		<-t.C
-		for i, r := range resp {
-			if r == nil {
+	backoff := v.RetryBackoff
+	for attempt := 0; attempt <= v.Retries; attempt++ {
+		if attempt > 0 {
+			v.event(&Event{Type: EventDownloadRetry, Version: ver.Name, Attempt: attempt, Retries: v.Retries, Backoff: backoff})
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		for _, url := range ver.DownloadUrls(v.Mirrors...) {
+			req, err2 := grab.NewRequest(ver.DownloadPath(), url)
+			if err2 != nil {
+				return errwrap.Wrap(err2, "New request for %q", url)
+			}
+			req = req.WithContext(ctx)
+			v.event(&Event{Type: EventDownloadStart, Version: ver.Name, URL: url})
+			resp := client.Do(req)
+			if resp.HTTPResponse != nil {
+				e := &Event{Type: EventDownloadResponse, Version: ver.Name, URL: url, Status: resp.HTTPResponse.Status, Total: -1}
+				switch resp.HTTPResponse.StatusCode {
+				case 200, 206:
+					e.Total = resp.HTTPResponse.ContentLength
+				}
+				v.event(e)
+			}
+			if resumeIgnored(resp) {
+				// the full body would be appended to the partial download
+				resp.Cancel()
+				if err = os.Remove(ver.DownloadPath()); err != nil && !os.IsNotExist(err) {
+					return errwrap.Wrap(err, "Remove partial download %q", ver.DownloadPath())
+				}
+				err = fmt.Errorf("Server of %q doesn't resumes the download.", url)
+				v.event(&Event{Type: EventDownloadURLFailed, Version: ver.Name, URL: url, Err: err})
 				continue
 			}
-			select {
-			case <-r.Done:
-				resp[i] = nil
-				l--
-
-				// check for errors
-				if err := r.Err(); err != nil {
-					fmt.Fprintf(os.Stderr, "[%v] Download failed: %v\n", versions[i].Name, err)
-				} else {
-					dok = append(dok, versions[i])
-					fmt.Printf("[%v] Download saved to %v\n", versions[i].Name, r.Filename)
+
+			t := time.NewTicker(2 * time.Second)
+		loop:
+			for {
+				select {
+				case <-resp.Done:
+					break loop
+				case <-t.C:
+					v.event(&Event{Type: EventDownloadProgress, Version: ver.Name, URL: url,
+						Bytes: resp.BytesComplete(), Total: resp.Size})
 				}
-			default:
-				fmt.Printf("[%v] transferred %v / %v (%.2f%%)\n",
-					versions[i].Name, hb(r.BytesComplete()), hb(r.Size), 100*r.Progress())
 			}
+			t.Stop()
+
+			if err = resp.Err(); err == nil {
+				v.event(&Event{Type: EventDownloadDone, Version: ver.Name, URL: url, Path: resp.Filename})
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			v.event(&Event{Type: EventDownloadURLFailed, Version: ver.Name, URL: url, Err: err})
 		}
 	}
-	return dok, nil
+	return err
+}
+
+// resumeIgnored reports whether the server responds to the resume request
+// with the full content.
+func resumeIgnored(resp *grab.Response) bool {
+	r := resp.HTTPResponse
+	return resp.DidResume && r != nil && r.Request.Method == http.MethodGet &&
+		r.Request.Header.Get("Range") != "" && r.StatusCode != http.StatusPartialContent
 }
 
 func (vs *GoVersions) Install(names ...string) (versions []*GoVersion, err error) {
-	versions, err = vs.Download(names...)
+	return vs.InstallContext(context.Background(), names...)
+}
+
+// InstallContext is like Install, but is canceled when ctx is done. The
+// partial extracted version is removed.
+func (vs *GoVersions) InstallContext(ctx context.Context, names ...string) (versions []*GoVersion, err error) {
+	vs, unlock, err := vs.Lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	versions, err = vs.DownloadContext(ctx, names...)
 	if err != nil {
 		return
 	}
 	for _, v := range versions {
-		_, err = os.Stat(v.Root)
-		if err == nil || !os.IsNotExist(err) {
-			err = os.RemoveAll(v.Root)
-			if err != nil {
-				return nil, errwrap.Wrap(err, "Remove %q", v.Root)
-			}
-		} else if !os.IsNotExist(err) {
-			return nil, errwrap.Wrap(err, "Stat of %q", v.Root)
+		if err = vs.extract(ctx, v); err != nil {
+			return nil, err
 		}
-		f, err := os.Open(v.DownloadPath())
-		if err != nil {
-			return nil, errwrap.Wrap(err, "Open %q", v.downloadPath)
+	}
+	return
+}
+
+// extract extracts the downloaded archive of version to its Root. On failure,
+// the partial Root is removed.
+func (vs *GoVersions) extract(ctx context.Context, v *GoVersion) (err error) {
+	if _, err := os.Lstat(v.Root); err == nil {
+		if err = os.RemoveAll(v.Root); err != nil {
+			return errwrap.Wrap(err, "Remove %q", v.Root)
 		}
-		bkp, err := NewBackupReader(f, false)
-		if err != nil {
-			return nil, errwrap.Wrap(err, "Reader %q", v.downloadPath)
+	} else if !os.IsNotExist(err) {
+		return errwrap.Wrap(err, "Stat of %q", v.Root)
+	}
+	f, err := os.Open(v.DownloadPath())
+	if err != nil {
+		return errwrap.Wrap(err, "Open %q", v.downloadPath)
+	}
+	bkp, err := NewBackupReader(f, false)
+	if err != nil {
+		f.Close()
+		return errwrap.Wrap(err, "Reader %q", v.downloadPath)
+	}
+	vs.event(&Event{Type: EventExtractStart, Version: v.Name, Path: v.downloadPath, Target: v.Root})
+	err = bkp.ExtractContext(ctx, v.Name, vs.Dir(), ExtractOptions(0))
+	f.Close()
+	vs.event(&Event{Type: EventExtractDone, Version: v.Name, Path: v.downloadPath, Target: v.Root, Err: err})
+	if err != nil {
+		if err2 := os.RemoveAll(v.Root); err2 != nil {
+			return errwrap.Wrap(err2, "Remove partial %q", v.Root)
 		}
-		fmt.Printf("[%v] Extract %q to %q...", v.Name, v.downloadPath, v.Root)
-
-		err = bkp.Extract(v.Name, vs.Dir(), ExtractOptions(0))
-		fmt.Println(" Done.")
-		if err != nil {
-			return nil, errwrap.Wrap(err, "Extract %q", v.downloadPath)
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
+		return errwrap.Wrap(err, "Extract %q", v.downloadPath)
 	}
-	return
+	if !vs.KeepArchives {
+		if err = os.Remove(v.downloadPath); err != nil {
+			return errwrap.Wrap(err, "Remove %q", v.downloadPath)
+		}
+	}
+	return nil
 }
 
 func (v *GoVersions) Available(desc bool, terms ...string) ([]*GoVersion, error) {
+	return v.AvailableContext(context.Background(), desc, terms...)
+}
+
+// AvailableContext is like Available, but the request of download page is
+// canceled when ctx is done.
+func (v *GoVersions) AvailableContext(ctx context.Context, desc bool, terms ...string) ([]*GoVersion, error) {
 	system, err := GetSystemGoVersion()
 	if err != nil {
 		return nil, err
@@ -363,9 +755,13 @@ func (v *GoVersions) Available(desc bool, terms ...string) ([]*GoVersion, error)
 		return nil, errwrap.Wrap(err, "GO isn't available on system. Please install it from https://golang.org/dl")
 	}
 
-	r, err := http.Get("https://golang.org/dl")
+	req, err := http.NewRequest(http.MethodGet, "https://golang.org/dl", nil)
+	if err != nil {
+		return nil, err
+	}
+	r, err := http.DefaultClient.Do(req.WithContext(ctx))
 	if err != nil {
-		return nil, errwrap.Wrap(err, "Get Milestones from %q", r.Request.URL)
+		return nil, errwrap.Wrap(err, "Get Milestones")
 	}
 	defer r.Body.Close()
 
@@ -457,6 +853,24 @@ func (v *GoVersions) Available(desc bool, terms ...string) ([]*GoVersion, error)
 	return versions, nil
 }
 
+// mirrorVersions returns the versions of exact names (without glob
+// characters) for download from mirrors.
+func (v *GoVersions) mirrorVersions(names ...string) (versions []*GoVersion, err error) {
+	for _, name := range names {
+		if globHasSpecial(name) {
+			return nil, fmt.Errorf("Version %q isn't exact name.", name)
+		}
+		name = "go" + strings.TrimPrefix(strings.ToLower(name), "go")
+		versions = append(versions, &GoVersion{
+			Name:     name,
+			ID:       sname(name),
+			fileName: name + "." + runtime.GOOS + "-" + runtime.GOARCH + ".tar.gz",
+			versions: v,
+		})
+	}
+	return
+}
+
 func sname(name string) (v string) {
 	parts := strings.Split(name[2:], ".")
 
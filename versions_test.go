@@ -0,0 +1,406 @@
+// Copyright © 2018 Moises P. Sena <moisespsena@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goenv
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cavaliercoder/grab"
+)
+
+var testArchive = bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+
+// dropHandler sends the half of data and drops the connection.
+func dropHandler(data []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Accept-Ranges", "bytes")
+		if r.Method == http.MethodHead {
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(data[:len(data)/2])
+		w.(http.Flusher).Flush()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}
+}
+
+// fileHandler serves data, with range requests support.
+func fileHandler(data []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}
+}
+
+type testLogger struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (l *testLogger) Event(e *Event) {
+	l.mu.Lock()
+	l.events = append(l.events, *e)
+	l.mu.Unlock()
+}
+
+func (l *testLogger) count(typ EventType) (n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range l.events {
+		if e.Type == typ {
+			n++
+		}
+	}
+	return
+}
+
+func newTestVersion(t *testing.T, mirrors ...string) (*GoVersions, *GoVersion, *testLogger) {
+	t.Helper()
+	logger := &testLogger{}
+	vs := NewGoVersions(newTestEnv(t), WithVersionsLogger(logger))
+	vs.Mirrors = mirrors
+	vs.RetryBackoff = time.Millisecond
+	versions, err := vs.mirrorVersions("go1.99")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(vs.Dir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return vs, versions[0], logger
+}
+
+func TestDownloadMirrorFallback(t *testing.T) {
+	broken := httptest.NewServer(dropHandler(testArchive))
+	defer broken.Close()
+	mirror := httptest.NewServer(fileHandler(testArchive))
+	defer mirror.Close()
+
+	vs, ver, logger := newTestVersion(t, broken.URL, mirror.URL+"/")
+	vs.Retries = 0
+	if err := vs.download(context.Background(), grab.NewClient(), ver); err != nil {
+		t.Fatal(err)
+	}
+	if data := readTestFile(t, ver.DownloadPath()); data != string(testArchive) {
+		t.Errorf("downloaded %d bytes, want %d", len(data), len(testArchive))
+	}
+	if n := logger.count(EventDownloadURLFailed); n != 1 {
+		t.Errorf("%d URLs failed, want 1", n)
+	}
+}
+
+func TestDownloadRetryResume(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	drop := dropHandler(testArchive)
+	serve := fileHandler(testArchive)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.Header.Get("Range"))
+		first := len(requests) == 1
+		mu.Unlock()
+		if first {
+			drop(w, r)
+		} else {
+			serve(w, r)
+		}
+	}))
+	defer server.Close()
+
+	vs, ver, logger := newTestVersion(t, server.URL)
+	vs.Retries = 1
+	if err := vs.download(context.Background(), grab.NewClient(), ver); err != nil {
+		t.Fatal(err)
+	}
+	if data := readTestFile(t, ver.DownloadPath()); data != string(testArchive) {
+		t.Errorf("downloaded %d bytes, want %d", len(data), len(testArchive))
+	}
+	if n := logger.count(EventDownloadRetry); n != 1 {
+		t.Errorf("%d retries, want 1", n)
+	}
+	resumed := false
+	for _, r := range requests {
+		if r == "GET bytes="+strconv.Itoa(len(testArchive)/2)+"-" {
+			resumed = true
+		}
+	}
+	if !resumed {
+		t.Errorf("download isn't resumed: %q", requests)
+	}
+}
+
+func TestDownloadFails(t *testing.T) {
+	broken := httptest.NewServer(dropHandler(testArchive))
+	defer broken.Close()
+
+	vs, ver, logger := newTestVersion(t, broken.URL)
+	vs.Retries = 2
+	if err := vs.download(context.Background(), grab.NewClient(), ver); err == nil {
+		t.Fatal("download of broken URL doesn't fails")
+	}
+	if n := logger.count(EventDownloadRetry); n != 2 {
+		t.Errorf("%d retries, want 2", n)
+	}
+}
+
+func TestDownloadResumeIgnored(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+	drop := dropHandler(testArchive)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		first := requests == 1
+		mu.Unlock()
+		if first {
+			drop(w, r)
+			return
+		}
+		// ignores the Range header
+		w.Header().Set("Content-Length", strconv.Itoa(len(testArchive)))
+		w.Header().Set("Accept-Ranges", "bytes")
+		if r.Method != http.MethodHead {
+			w.Write(testArchive)
+		}
+	}))
+	defer server.Close()
+
+	vs, ver, _ := newTestVersion(t, server.URL)
+	vs.Retries = 2
+	if err := vs.download(context.Background(), grab.NewClient(), ver); err != nil {
+		t.Fatal(err)
+	}
+	if data := readTestFile(t, ver.DownloadPath()); data != string(testArchive) {
+		t.Errorf("downloaded content is corrupted (%d bytes)", len(data))
+	}
+}
+
+// newTestGoRoot returns the fake GOROOT with bin/go reporting the version.
+func newTestGoRoot(t *testing.T, version string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake go binary is a shell script")
+	}
+	root := filepath.Join(t.TempDir(), "go")
+	pth := filepath.Join(root, "bin", "go")
+	writeTestFile(t, pth, "#!/bin/sh\necho go version "+version+" "+runtime.GOOS+"/"+runtime.GOARCH+"\n")
+	if err := os.Chmod(pth, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestAddBinaryLink(t *testing.T) {
+	root := newTestGoRoot(t, "go1.99")
+	bin := filepath.Join(t.TempDir(), "bin")
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// like /usr/bin/go -> /usr/lib/go-1.99/bin/go
+	if err := os.Symlink(filepath.Join(root, "bin", "go"), filepath.Join(bin, "go")); err != nil {
+		t.Fatal(err)
+	}
+	vs := NewGoVersions(newTestEnv(t))
+	v, err := vs.Add("go1.99-distro", filepath.Join(bin, "go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := filepath.EvalSymlinks(root); v.LinkTarget != want {
+		t.Errorf("LinkTarget = %q, want %q", v.LinkTarget, want)
+	}
+}
+
+func TestRmInUse(t *testing.T) {
+	root := newTestGoRoot(t, "go1.99")
+	vs := NewGoVersions(newTestEnv(t, "e1"))
+	if _, err := vs.Add("go1.99-distro", root); err != nil {
+		t.Fatal(err)
+	}
+	if err := vs.Set("go1.99-distro", "e1"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := vs.Rm("go1.99-distro", false)
+	if !errors.Is(err, ErrVersionInUse) {
+		t.Fatalf("Rm of version in use: %v, want ErrVersionInUse", err)
+	}
+	if !strings.Contains(err.Error(), "e1") {
+		t.Errorf("error %q doesn't reports the enviroment", err)
+	}
+	if err = vs.Rm("go1.99-distro", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Lstat(filepath.Join(vs.Dir(), "go1.99-distro")); !os.IsNotExist(err) {
+		t.Errorf("version isn't removed: %v", err)
+	}
+	if err = vs.Rm("go1.99-distro", false); !errors.Is(err, ErrVersionNotInstalled) {
+		t.Errorf("Rm of removed version: %v, want ErrVersionNotInstalled", err)
+	}
+}
+
+func TestSetSysPinLinkedBinary(t *testing.T) {
+	root := newTestGoRoot(t, "go1.99")
+	bin := filepath.Join(t.TempDir(), "bin")
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "bin", "go"), filepath.Join(bin, "go")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOROOT", "")
+	t.Setenv("PATH", bin)
+
+	env := newTestEnv(t, "e1")
+	if err := NewGoVersions(env).Set(SYS_PIN_VERSION, "e1"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := env.EnvInfo("e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := filepath.EvalSymlinks(root)
+	if info.Binding.Root != want || info.GoRoot != want {
+		t.Errorf("GOROOT = %q (bound %q), want %q", info.GoRoot, info.Binding.Root, want)
+	}
+	if info.Broken {
+		t.Error("enviroment is broken")
+	}
+}
+
+func TestDownloadCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(testArchive)))
+		if r.Method == http.MethodHead {
+			return
+		}
+		w.Write(testArchive[:len(testArchive)/2])
+		w.(http.Flusher).Flush()
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	vs, ver, _ := newTestVersion(t, server.URL)
+	if err := vs.download(ctx, grab.NewClient(), ver); err != context.Canceled {
+		t.Fatalf("%v, want context.Canceled", err)
+	}
+	if _, err := os.Stat(ver.DownloadPath()); !os.IsNotExist(err) {
+		t.Errorf("partial download isn't removed: %v", err)
+	}
+}
+
+// cancelAfterCtx is canceled on the n-th call of Err, so the operations are
+// canceled partway.
+type cancelAfterCtx struct {
+	context.Context
+	cancel context.CancelFunc
+	n      int32
+}
+
+func cancelAfter(t *testing.T, n int32) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return &cancelAfterCtx{ctx, cancel, n}
+}
+
+func (c *cancelAfterCtx) Err() error {
+	if atomic.AddInt32(&c.n, -1) <= 0 {
+		c.cancel()
+	}
+	return c.Context.Err()
+}
+
+func TestExtractCancel(t *testing.T) {
+	vs, ver, _ := newTestVersion(t)
+	ver.Root = filepath.Join(vs.Dir(), ver.Name)
+
+	// the Go archive has the root dir "go"
+	src := filepath.Join(t.TempDir(), "go")
+	for i := 0; i < 20; i++ {
+		writeTestFile(t, filepath.Join(src, "src", fmt.Sprintf("f%02d.go", i)), string(testArchive[:32*1024]))
+	}
+	f, err := os.Create(ver.DownloadPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = compress(context.Background(), src, f, BACKUP_CODEC_GZIP, nil, nil, nil)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = vs.extract(cancelAfter(t, 10), ver); err != context.Canceled {
+		t.Fatalf("%v, want context.Canceled", err)
+	}
+	if _, err = os.Stat(ver.Root); !os.IsNotExist(err) {
+		t.Errorf("partial version isn't removed: %v", err)
+	}
+
+	if err = vs.extract(context.Background(), ver); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := IsFile(ver.Root, "src", "f19.go"); !ok {
+		t.Error("version isn't extracted")
+	}
+	if _, err = os.Stat(ver.DownloadPath()); !os.IsNotExist(err) {
+		t.Errorf("archive isn't removed: %v", err)
+	}
+}
+
+func TestResolveMinorLine(t *testing.T) {
+	vs := NewGoVersions(newTestEnv(t))
+	for _, name := range []string{"go1.21.3", "go1.21.10", "go1.21.11-custom", "go1.22rc1", "go1.2.1"} {
+		if _, err := vs.Add(name, newTestGoRoot(t, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for version, want := range map[string]string{
+		"1.21":      "go1.21.10",
+		"go1.21":    "go1.21.10",
+		"1.21.3":    "go1.21.3",
+		"1.2":       "go1.2.1",
+		"go1.22rc1": "go1.22rc1",
+	} {
+		name, err := vs.Resolve(version, false)
+		if err != nil {
+			t.Errorf("%s: %v", version, err)
+		} else if name != want {
+			t.Errorf("%s resolves to %s, want %s", version, name, want)
+		}
+	}
+}